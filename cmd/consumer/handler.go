@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"upframer-worker/internal/application/usecases"
+	customerrors "upframer-worker/internal/domain/errors"
+	"upframer-worker/internal/infra/rabbit"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+type JobHandler struct {
+	queueName string
+	useCase   *usecases.ProcessVideoUseCase
+	publisher *rabbit.RabbitPublisher
+	client    *rabbit.RabbitMQ
+	retry     rabbit.RetryPolicy
+}
+
+func NewJobHandler(queueName string, useCase *usecases.ProcessVideoUseCase, publisher *rabbit.RabbitPublisher, client *rabbit.RabbitMQ, retry rabbit.RetryPolicy) *JobHandler {
+	return &JobHandler{
+		queueName: queueName,
+		useCase:   useCase,
+		publisher: publisher,
+		client:    client,
+		retry:     retry,
+	}
+}
+
+func (h *JobHandler) Handle(ctx context.Context, msg amqp091.Delivery) {
+	log.Println("New message received, processing...")
+
+	retryCount := int32(0)
+	if msg.Headers != nil {
+		if count, ok := msg.Headers["x-retry-count"].(int32); ok {
+			retryCount = count
+		}
+	}
+
+	err := h.execute(ctx, msg.Body, retryCount)
+
+	if err == nil {
+		msg.Ack(false)
+		log.Println("Successfully processed!")
+		return
+	}
+
+	if ctx.Err() != nil {
+		log.Printf("Job interrupted by shutdown, requeueing: %v", err)
+		msg.Nack(false, true)
+		return
+	}
+
+	log.Printf("Error when processing: %v", err)
+
+	if customerrors.IsPermanentError(err) {
+		log.Printf("Permanent error detected. Sending to DLQ without retry.")
+		h.deadLetter(msg, err, retryCount)
+		return
+	}
+
+	if int(retryCount) >= h.retry.MaxAttempts {
+		log.Printf("Max retries (%d) exceeded. Sending to DLQ.", h.retry.MaxAttempts)
+		h.deadLetter(msg, err, retryCount)
+		return
+	}
+
+	log.Printf("Temporary error. Retry %d/%d", retryCount+1, h.retry.MaxAttempts)
+	if retryErr := h.client.ScheduleRetry(h.queueName, msg.Body, retryCount, h.retry); retryErr != nil {
+		log.Printf("Failed to schedule retry: %v. Returning message to the queue.", retryErr)
+		msg.Nack(false, true)
+		return
+	}
+	msg.Nack(false, false)
+}
+
+func (h *JobHandler) deadLetter(msg amqp091.Delivery, err error, retryCount int32) {
+	if dlqErr := h.publisher.PublishToDLQ(h.queueName, msg.Body, err.Error(), customerrors.Detail(err), retryCount); dlqErr != nil {
+		log.Printf("Failed to send to DLQ: %v. Returning message to the queue.", dlqErr)
+		msg.Nack(false, true)
+		return
+	}
+	msg.Nack(false, false)
+}
+
+func (h *JobHandler) execute(ctx context.Context, body []byte, retryCount int32) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while processing job: %v", r)
+		}
+	}()
+
+	return h.useCase.Execute(ctx, body, int(retryCount))
+}
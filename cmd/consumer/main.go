@@ -1,50 +1,111 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 	"upframer-worker/internal/application/usecases"
-	customerrors "upframer-worker/internal/domain/errors"
+	"upframer-worker/internal/domain/entities"
 	"upframer-worker/internal/domain/ports"
 	"upframer-worker/internal/infra/ffmpeg"
 	"upframer-worker/internal/infra/rabbit"
 	"upframer-worker/internal/infra/storage"
+	"upframer-worker/internal/infra/workspace"
 
 	"github.com/joho/godotenv"
 )
 
-const (
-	maxRetries = 3
-)
-
-func init() {
-	rabbit.NewRabbitMQConnection()
-}
+var defaultSupportedCodecs = []string{"h264", "hevc", "vp8", "vp9", "av1", "mpeg4", "mpeg2video", "mpeg1video", "prores", "mjpeg", "theora", "wmv3", "vc1", "h263"}
 
 func main() {
-	defer rabbit.RabbitMQClient.CloseConnection()
+	_ = godotenv.Load()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rabbitClient, err := rabbit.NewRabbitMQConnection(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	queueName := "job-creation"
 
-	if err := rabbit.RabbitMQClient.SetupDLQ(queueName); err != nil {
+	if err := rabbitClient.SetupDLQ(queueName); err != nil {
 		log.Fatalf("Failed to setup DLQ: %v", err)
 	}
 
-	msgs, err := rabbit.RabbitMQClient.ConsumeRabbitMQQueue(queueName)
+	retryPolicy := rabbit.RetryPolicy{
+		BaseDelay:   envDuration("RETRY_BASE_DELAY", 5*time.Second),
+		Multiplier:  envFloat("RETRY_MULTIPLIER", 2),
+		Jitter:      envFloat("RETRY_JITTER", 0.2),
+		MaxAttempts: envInt("RETRY_MAX_ATTEMPTS", 3),
+	}
+
+	if retryPolicy.Jitter > 1 {
+		log.Fatalf("Invalid RETRY_JITTER: must be between 0 and 1")
+	}
+
+	if err := rabbitClient.SetupRetry(queueName, retryPolicy); err != nil {
+		log.Fatalf("Failed to setup retry queues: %v", err)
+	}
+
+	concurrency := envInt("WORKER_CONCURRENCY", 1)
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	msgs, err := rabbitClient.ConsumeRabbitMQQueue(queueName, concurrency)
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	_ = godotenv.Load()
-
 	bucket := os.Getenv("AWS_BUCKET")
 	region := os.Getenv("AWS_REGION")
 	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
 	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
-	sessionToken := os.Getenv("AWS_SESSION_TOKEN")
 	environment := os.Getenv("ENVIRONMENT")
 
+	s3Config := storage.S3Config{
+		Bucket:             bucket,
+		Region:             region,
+		AccessKey:          accessKey,
+		SecretKey:          secretKey,
+		SessionToken:       os.Getenv("AWS_SESSION_TOKEN"),
+		UploadConcurrency:  envInt("UPLOAD_CONCURRENCY", 8),
+		PresignExpiry:      envDuration("PRESIGN_URL_EXPIRY", 0),
+		ContentDisposition: os.Getenv("PRESIGN_CONTENT_DISPOSITION"),
+		Endpoint:           os.Getenv("S3_ENDPOINT"),
+		UsePathStyle:       envBool("S3_FORCE_PATH_STYLE", false),
+		CABundle:           os.Getenv("S3_CA_BUNDLE"),
+		InsecureSkipVerify: envBool("S3_INSECURE_SKIP_VERIFY", false),
+	}
+
+	if s3Config.Endpoint != "" {
+		if endpoint, err := url.Parse(s3Config.Endpoint); err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			log.Fatalf("Invalid S3_ENDPOINT %q: must be an http(s) URL such as http://localhost:9000", s3Config.Endpoint)
+		}
+	}
+
+	// SigV4 presigned URLs are valid for at most seven days.
+	if s3Config.PresignExpiry > 7*24*time.Hour {
+		log.Fatalf("Invalid PRESIGN_URL_EXPIRY %s: must be at most 168h", s3Config.PresignExpiry)
+	}
+	switch s3Config.ContentDisposition {
+	case "", storage.DispositionAttachment, storage.DispositionInline:
+	default:
+		log.Fatalf("Invalid PRESIGN_CONTENT_DISPOSITION %q: must be attachment or inline", s3Config.ContentDisposition)
+	}
+
 	var storageAdapter ports.Storage
 
 	if environment == "production" {
@@ -52,7 +113,7 @@ func main() {
 			log.Fatal("FATAL: S3 credentials are required in production environment. Set AWS_BUCKET, AWS_REGION, AWS_ACCESS_KEY_ID, and AWS_SECRET_ACCESS_KEY")
 		}
 
-		s3Storage, err := storage.NewS3Storage(bucket, region, accessKey, secretKey, sessionToken)
+		s3Storage, err := storage.NewS3Storage(s3Config)
 		if err != nil {
 			log.Fatalf("FATAL: Failed to initialize S3 storage in production: %v", err)
 		}
@@ -60,7 +121,7 @@ func main() {
 		log.Println("Using S3 storage (production mode)")
 	} else {
 		if bucket != "" && region != "" && accessKey != "" && secretKey != "" {
-			s3Storage, err := storage.NewS3Storage(bucket, region, accessKey, secretKey, sessionToken)
+			s3Storage, err := storage.NewS3Storage(s3Config)
 			if err != nil {
 				log.Printf("Failed to initialize S3 storage: %v. Using local storage as fallback.", err)
 				storageAdapter = storage.NewLocalStorage("./output")
@@ -74,72 +135,196 @@ func main() {
 		}
 	}
 
-	processor := ffmpeg.NewFFmpegProcessor(storageAdapter)
-	publisher := rabbit.NewRabbitPublisher(rabbit.RabbitMQClient)
-	processVideoUseCase := usecases.NewProcessVideoUseCase(processor, publisher)
+	workspaceRoot := os.Getenv("WORKSPACE_ROOT")
+	if workspaceRoot == "" {
+		workspaceRoot = filepath.Join(os.TempDir(), "upframer")
+	}
+
+	workspaceMaxAge := envDuration("WORKSPACE_MAX_AGE", 24*time.Hour)
+
+	diskBudget := workspace.NewDiskBudget(int64(envInt("MAX_INFLIGHT_DISK_MB", 0)) << 20)
+
+	workspaces, err := workspace.NewManager(workspaceRoot, workspaceMaxAge, diskBudget)
+	if err != nil {
+		log.Fatalf("Failed to initialize workspaces: %v", err)
+	}
 
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	reclaimed, err := workspaces.ReclaimOrphans()
+	if err != nil {
+		log.Printf("Failed to reclaim orphaned workspaces: %v", err)
+	} else if reclaimed > 0 {
+		log.Printf("Reclaimed %d orphaned workspaces from %s", reclaimed, workspaceRoot)
+	}
+
+	workerId := os.Getenv("WORKER_ID")
+	if workerId == "" {
+		workerId, _ = os.Hostname()
+	}
+
+	archiveFormat := os.Getenv("ARCHIVE_FORMAT")
+	if archiveFormat == "" {
+		archiveFormat = entities.ArchiveZip
+	}
+	if !entities.IsArchiveFormat(archiveFormat) {
+		log.Fatalf("Invalid ARCHIVE_FORMAT %q", archiveFormat)
+	}
+
+	publisher := rabbit.NewRabbitPublisher(rabbitClient)
+	if err := publisher.DeclareQueues(usecases.ResultQueueName, ffmpeg.ProgressQueueName); err != nil {
+		log.Fatalf("Failed to declare result queues: %v", err)
+	}
+	processor := ffmpeg.NewFFmpegProcessor(storageAdapter, workspaces, publisher, ffmpeg.Config{
+		WorkerId:              workerId,
+		ProgressInterval:      envDuration("PROGRESS_INTERVAL", 5*time.Second),
+		JobTimeout:            envDuration("JOB_TIMEOUT", 30*time.Minute),
+		TimeoutPerVideoSecond: envFloat("JOB_TIMEOUT_PER_VIDEO_SECOND", 0),
+		ArchiveFormat:         archiveFormat,
+		UnknownSourceSize:     int64(envInt("UNKNOWN_VIDEO_SIZE_MB", 1024)) << 20,
+		StorageEndpoint:       s3Config.Endpoint,
+		Limits: ffmpeg.ProbeLimits{
+			MaxDuration:     envDuration("MAX_VIDEO_DURATION", 0),
+			MaxWidth:        envInt("MAX_VIDEO_WIDTH", 0),
+			MaxHeight:       envInt("MAX_VIDEO_HEIGHT", 0),
+			MaxSize:         int64(envInt("MAX_VIDEO_SIZE_MB", 0)) << 20,
+			SupportedCodecs: envList("SUPPORTED_VIDEO_CODECS", defaultSupportedCodecs),
+		},
+	})
+	processVideoUseCase := usecases.NewProcessVideoUseCase(processor, publisher, retryPolicy.MaxAttempts)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		if state := rabbitClient.State(); state != rabbit.StateConnected {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("RabbitMQ " + string(state)))
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
+	port := os.Getenv("HEALTH_CHECK_PORT")
+	if port == "" {
+		port = "3334"
+	}
+	healthServer := &http.Server{Addr: ":" + port, Handler: mux}
+
 	go func() {
-		port := os.Getenv("HEALTH_CHECK_PORT")
-		if port == "" {
-			port = "3334"
-		}
 		log.Printf("Health check server starting on port %s", port)
-		if err := http.ListenAndServe(":"+port, nil); err != nil {
+		if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Printf("Health check server error: %v", err)
 		}
 	}()
 
-	forever := make(chan bool)
+	shutdownTimeout := envDuration("SHUTDOWN_TIMEOUT", 25*time.Second)
+
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	handler := NewJobHandler(queueName, processVideoUseCase, publisher, rabbitClient, retryPolicy)
+	pool := NewWorkerPool(concurrency, handler.Handle)
+
+	poolDone := make(chan struct{})
 	go func() {
-		for msg := range msgs {
-			log.Println("New message received, processing...")
-
-			retryCount := int32(0)
-			if msg.Headers != nil {
-				if count, ok := msg.Headers["x-retry-count"].(int32); ok {
-					retryCount = count
-				}
-			}
+		pool.Run(ctx, jobsCtx, msgs)
+		close(poolDone)
+	}()
 
-			err := processVideoUseCase.Execute(msg.Body)
+	log.Printf("Processing up to %d jobs concurrently", concurrency)
 
-			if err != nil {
-				log.Printf("Error when processing: %v", err)
-
-				if customerrors.IsPermanentError(err) {
-					log.Printf("Permanent error detected. Sending to DLQ without retry.")
-					if dlqErr := publisher.PublishToDLQ(queueName, msg.Body, err.Error(), retryCount); dlqErr != nil {
-						log.Printf("Failed to send to DLQ: %v", dlqErr)
-					}
-					msg.Nack(false, false)
-					continue
-				}
-
-				if retryCount >= maxRetries {
-					log.Printf("Max retries (%d) exceeded. Sending to DLQ.", maxRetries)
-					if dlqErr := publisher.PublishToDLQ(queueName, msg.Body, err.Error(), retryCount); dlqErr != nil {
-						log.Printf("Failed to send to DLQ: %v", dlqErr)
-					}
-					msg.Nack(false, false)
-					continue
-				}
-
-				log.Printf("Temporary error. Retry %d/%d", retryCount+1, maxRetries)
-				if requeueErr := rabbit.RabbitMQClient.RequeuWithRetryCount(queueName, msg.Body, retryCount); requeueErr != nil {
-					log.Printf("Failed to requeue message: %v", requeueErr)
-				}
-				msg.Nack(false, false)
-			} else {
-				msg.Ack(false)
-				log.Println("Successfully processed!")
-			}
+	select {
+	case <-ctx.Done():
+		log.Printf("Shutdown signal received. Draining in-flight jobs (timeout %s)...", shutdownTimeout)
+	case <-poolDone:
+		log.Println("Delivery channel closed. Shutting down...")
+	}
+	stop()
+
+	if err := rabbitClient.StopConsuming(); err != nil {
+		log.Printf("Failed to stop consuming: %v", err)
+	}
+
+	select {
+	case <-poolDone:
+		log.Println("All in-flight jobs finished")
+	case <-time.After(shutdownTimeout):
+		log.Println("Shutdown timeout exceeded. Cancelling remaining jobs...")
+		cancelJobs()
+		<-poolDone
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := healthServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Health check server shutdown error: %v", err)
+	}
+
+	rabbitClient.CloseConnection()
+	log.Println("Worker stopped")
+}
+
+func envList(name string, fallback []string) []string {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
-	}()
+	}
+	return items
+}
+
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		log.Fatalf("Invalid %s: %q", name, value)
+	}
+	return parsed
+}
+
+func envBool(name string, fallback bool) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
 
-	<-forever
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %q", name, value)
+	}
+	return parsed
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %q", name, value)
+	}
+	return parsed
+}
+
+func envFloat(name string, fallback float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 {
+		log.Fatalf("Invalid %s: %q", name, value)
+	}
+	return parsed
 }
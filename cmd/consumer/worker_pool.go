@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+type WorkerPool struct {
+	slots   chan struct{}
+	handler func(context.Context, amqp091.Delivery)
+	wg      sync.WaitGroup
+}
+
+func NewWorkerPool(concurrency int, handler func(context.Context, amqp091.Delivery)) *WorkerPool {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &WorkerPool{
+		slots:   make(chan struct{}, concurrency),
+		handler: handler,
+	}
+}
+
+// Run dispatches deliveries until msgs is closed or ctx is cancelled, then
+// waits for in-flight jobs. Jobs run under jobsCtx so they can outlive ctx
+// during a graceful drain.
+func (p *WorkerPool) Run(ctx, jobsCtx context.Context, msgs <-chan amqp091.Delivery) {
+	defer p.wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+
+			select {
+			case p.slots <- struct{}{}:
+			case <-ctx.Done():
+				msg.Nack(false, true)
+				return
+			}
+
+			p.wg.Add(1)
+			go func(msg amqp091.Delivery) {
+				defer func() {
+					<-p.slots
+					p.wg.Done()
+				}()
+				p.handler(jobsCtx, msg)
+			}(msg)
+		}
+	}
+}
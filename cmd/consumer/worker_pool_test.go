@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+type fakeAcknowledger struct {
+	mu      sync.Mutex
+	nacked  []uint64
+	requeue []bool
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nacked = append(a.nacked, tag)
+	a.requeue = append(a.requeue, requeue)
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return nil
+}
+
+func TestWorkerPool_RunsUntilDeliveriesClose(t *testing.T) {
+	var mu sync.Mutex
+	handled := 0
+
+	pool := NewWorkerPool(2, func(ctx context.Context, msg amqp091.Delivery) {
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	})
+
+	msgs := make(chan amqp091.Delivery, 5)
+	for i := 0; i < 5; i++ {
+		msgs <- amqp091.Delivery{DeliveryTag: uint64(i + 1)}
+	}
+	close(msgs)
+
+	pool.Run(context.Background(), context.Background(), msgs)
+
+	if handled != 5 {
+		t.Errorf("Expected 5 deliveries handled, got %d", handled)
+	}
+}
+
+func TestWorkerPool_NacksOnShutdown(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	pool := NewWorkerPool(1, func(ctx context.Context, msg amqp091.Delivery) {
+		close(started)
+		<-release
+	})
+
+	acknowledger := &fakeAcknowledger{}
+	msgs := make(chan amqp091.Delivery, 2)
+	msgs <- amqp091.Delivery{Acknowledger: acknowledger, DeliveryTag: 1}
+	msgs <- amqp091.Delivery{Acknowledger: acknowledger, DeliveryTag: 2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx, context.Background(), msgs)
+		close(done)
+	}()
+
+	<-started
+	// the second delivery is waiting for the only slot
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+		t.Fatal("Expected Run to wait for the in-flight job")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	<-done
+
+	acknowledger.mu.Lock()
+	defer acknowledger.mu.Unlock()
+	if len(acknowledger.nacked) != 1 || acknowledger.nacked[0] != 2 || !acknowledger.requeue[0] {
+		t.Errorf("Expected delivery 2 nacked with requeue, got %v %v", acknowledger.nacked, acknowledger.requeue)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+	"upframer-worker/internal/infra/rabbit"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: dlqctl <command> [flags]
+
+Commands:
+  list     List DLQ messages with their headers
+  replay   Send DLQ messages back to their original queue with the retry counter reset
+  purge    Remove DLQ messages
+  export   Write DLQ messages to a JSONL file
+
+Run 'dlqctl <command> -h' for the flags of each command.
+`
+
+type options struct {
+	queue   string
+	filter  rabbit.DLQFilter
+	limit   int
+	all     bool
+	json    bool
+	output  string
+	remove  bool
+	timeout time.Duration
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	opts := options{}
+	flags.StringVar(&opts.queue, "queue", "job-creation", "original queue whose DLQ is inspected")
+	flags.StringVar(&opts.filter.Reason, "reason", "", "only messages whose failure reason contains this text")
+	flags.StringVar(&opts.filter.JobId, "job", "", "only messages for this job ID")
+	flags.IntVar(&opts.limit, "limit", 0, "maximum number of matching messages (0 = no limit)")
+	flags.DurationVar(&opts.timeout, "timeout", 10*time.Second, "how long to keep trying to connect to RabbitMQ")
+
+	switch command {
+	case "list":
+		flags.BoolVar(&opts.json, "json", false, "print messages as JSON lines")
+	case "replay", "purge":
+		flags.BoolVar(&opts.all, "all", false, "allow the command to run without -reason or -job")
+	case "export":
+		flags.StringVar(&opts.output, "o", "dlq.jsonl", "output file")
+		flags.BoolVar(&opts.remove, "purge", false, "remove exported messages from the DLQ")
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+
+	flags.Parse(os.Args[2:])
+
+	if (command == "replay" || command == "purge") && opts.filter.IsEmpty() && !opts.all {
+		log.Fatalf("%s affects every DLQ message; pass -reason, -job or -all", command)
+	}
+
+	_ = godotenv.Load()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// the worker retries forever; a CLI with a bad URL should give up instead
+	connectCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+	client, err := rabbit.NewRabbitMQConnection(connectCtx)
+	cancel()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.CloseConnection()
+
+	inspector := rabbit.NewDLQInspector(client, opts.queue)
+
+	switch command {
+	case "list":
+		err = list(inspector, opts)
+	case "replay":
+		var replayed int
+		replayed, err = inspector.Replay(opts.filter, opts.limit)
+		log.Printf("Replayed %d messages", replayed)
+	case "purge":
+		var purged int
+		purged, err = inspector.Purge(opts.filter, opts.limit)
+		log.Printf("Purged %d messages", purged)
+	case "export":
+		err = export(inspector, opts)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func list(inspector *rabbit.DLQInspector, opts options) error {
+	messages, err := inspector.List(opts.filter, opts.limit)
+	if err != nil {
+		return err
+	}
+
+	if opts.json {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, msg := range messages {
+			if err := encoder.Encode(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB ID\tRETRIES\tORIGINAL QUEUE\tFAILURE REASON")
+	for _, msg := range messages {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", msg.JobId, msg.RetryCount, msg.OriginalQueue, strings.ReplaceAll(msg.FailureReason, "\n", " "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	log.Printf("%d messages", len(messages))
+	return nil
+}
+
+func export(inspector *rabbit.DLQInspector, opts options) error {
+	file, err := os.Create(opts.output)
+	if err != nil {
+		return fmt.Errorf("error creating export file: %v", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	exported, err := inspector.Export(opts.filter, opts.limit, opts.remove, func(msg rabbit.DLQMessage) error {
+		return encoder.Encode(msg)
+	}, func() error {
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("error writing export file: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Exported %d messages to %s", exported, opts.output)
+	return nil
+}
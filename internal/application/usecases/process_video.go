@@ -1,43 +1,79 @@
 package usecases
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"upframer-worker/internal/domain/entities"
+	customerrors "upframer-worker/internal/domain/errors"
 	"upframer-worker/internal/domain/services"
 )
 
+const ResultQueueName = "video-processing-result"
+
 type ProcessVideoUseCase struct {
-	processor services.VideoProcessor
-	publisher services.Publisher
+	processor  services.VideoProcessor
+	publisher  services.Publisher
+	maxRetries int
 }
 
-func NewProcessVideoUseCase(processor services.VideoProcessor, publisher services.Publisher) *ProcessVideoUseCase {
+func NewProcessVideoUseCase(processor services.VideoProcessor, publisher services.Publisher, maxRetries int) *ProcessVideoUseCase {
 	return &ProcessVideoUseCase{
-		processor: processor,
-		publisher: publisher,
+		processor:  processor,
+		publisher:  publisher,
+		maxRetries: maxRetries,
 	}
 }
 
-func (p *ProcessVideoUseCase) Execute(messageRawData []byte) error {
+func (p *ProcessVideoUseCase) Execute(ctx context.Context, messageRawData []byte, retryCount int) error {
 
 	var job entities.VideoJob
 
 	if err := json.Unmarshal(messageRawData, &job); err != nil {
 		log.Printf("error parsing message: %v", err)
+		return fmt.Errorf("%w: %v", customerrors.ErrInvalidMessageFormat, err)
+	}
+
+	if err := job.Validate(); err != nil {
+		log.Printf("invalid job %s: %v", job.JobId, err)
+		p.publishFailure(ctx, &job, nil, err, retryCount)
 		return err
 	}
 
-	result, err := p.processor.ProcessVideo(&job)
+	result, err := p.processor.ProcessVideo(ctx, &job)
 
 	if err != nil {
+		p.publishFailure(ctx, &job, result, err, retryCount)
 		return err
 	}
 
-	if err := p.publisher.Publish("video-processing-result", result); err != nil {
+	if err := p.publisher.Publish(ResultQueueName, result); err != nil {
 		log.Printf("Error publishing result: %v", err)
 		return err
 	}
 
 	return nil
 }
+
+func (p *ProcessVideoUseCase) publishFailure(ctx context.Context, job *entities.VideoJob, result *entities.ProcessingResult, err error, retryCount int) {
+	// Interrupted jobs are requeued as-is, so they are neither failed nor retrying.
+	if ctx.Err() != nil {
+		return
+	}
+
+	if result == nil {
+		result = &entities.ProcessingResult{JobId: job.JobId}
+	}
+
+	result.Status = entities.StatusFailed
+	if !customerrors.IsPermanentError(err) && retryCount < p.maxRetries {
+		result.Status = entities.StatusRetrying
+	}
+	result.ErrorCode = customerrors.ErrorCode(err)
+	result.ErrorMessage = err.Error()
+
+	if publishErr := p.publisher.Publish(ResultQueueName, result); publishErr != nil {
+		log.Printf("Error publishing %s result for job %s: %v", result.Status, job.JobId, publishErr)
+	}
+}
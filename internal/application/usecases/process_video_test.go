@@ -1,17 +1,20 @@
 package usecases
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"upframer-worker/internal/domain/entities"
+	customerrors "upframer-worker/internal/domain/errors"
 )
 
 type MockVideoProcessor struct {
 	processVideoFunc func(job *entities.VideoJob) (*entities.ProcessingResult, error)
 }
 
-func (m *MockVideoProcessor) ProcessVideo(job *entities.VideoJob) (*entities.ProcessingResult, error) {
+func (m *MockVideoProcessor) ProcessVideo(ctx context.Context, job *entities.VideoJob) (*entities.ProcessingResult, error) {
 	if m.processVideoFunc != nil {
 		return m.processVideoFunc(job)
 	}
@@ -33,11 +36,15 @@ func (m *MockPublisher) Publish(queueName string, result *entities.ProcessingRes
 	return nil
 }
 
+func (m *MockPublisher) PublishProgress(queueName string, event *entities.ProgressEvent) error {
+	return nil
+}
+
 func TestNewProcessVideoUseCase(t *testing.T) {
 	processor := &MockVideoProcessor{}
 	publisher := &MockPublisher{}
 
-	useCase := NewProcessVideoUseCase(processor, publisher)
+	useCase := NewProcessVideoUseCase(processor, publisher, 3)
 
 	if useCase == nil {
 		t.Fatal("Expected non-nil ProcessVideoUseCase")
@@ -53,7 +60,7 @@ func TestNewProcessVideoUseCase(t *testing.T) {
 func TestProcessVideoUseCase_Execute_Success(t *testing.T) {
 	processor := &MockVideoProcessor{}
 	publisher := &MockPublisher{}
-	useCase := NewProcessVideoUseCase(processor, publisher)
+	useCase := NewProcessVideoUseCase(processor, publisher, 3)
 
 	job := entities.VideoJob{
 		VideoName: "test-video.mp4",
@@ -63,7 +70,7 @@ func TestProcessVideoUseCase_Execute_Success(t *testing.T) {
 
 	messageData, _ := json.Marshal(job)
 
-	err := useCase.Execute(messageData)
+	err := useCase.Execute(context.Background(), messageData, 0)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -72,11 +79,11 @@ func TestProcessVideoUseCase_Execute_Success(t *testing.T) {
 func TestProcessVideoUseCase_Execute_InvalidJSON(t *testing.T) {
 	processor := &MockVideoProcessor{}
 	publisher := &MockPublisher{}
-	useCase := NewProcessVideoUseCase(processor, publisher)
+	useCase := NewProcessVideoUseCase(processor, publisher, 3)
 
 	invalidJSON := []byte(`{"invalid json}`)
 
-	err := useCase.Execute(invalidJSON)
+	err := useCase.Execute(context.Background(), invalidJSON, 0)
 	if err == nil {
 		t.Error("Expected error for invalid JSON")
 	}
@@ -89,7 +96,7 @@ func TestProcessVideoUseCase_Execute_ProcessorError(t *testing.T) {
 		},
 	}
 	publisher := &MockPublisher{}
-	useCase := NewProcessVideoUseCase(processor, publisher)
+	useCase := NewProcessVideoUseCase(processor, publisher, 3)
 
 	job := entities.VideoJob{
 		VideoName: "test-video.mp4",
@@ -99,7 +106,7 @@ func TestProcessVideoUseCase_Execute_ProcessorError(t *testing.T) {
 
 	messageData, _ := json.Marshal(job)
 
-	err := useCase.Execute(messageData)
+	err := useCase.Execute(context.Background(), messageData, 0)
 	if err == nil {
 		t.Error("Expected error from processor")
 	}
@@ -115,7 +122,7 @@ func TestProcessVideoUseCase_Execute_PublisherError(t *testing.T) {
 			return errors.New("publisher error")
 		},
 	}
-	useCase := NewProcessVideoUseCase(processor, publisher)
+	useCase := NewProcessVideoUseCase(processor, publisher, 3)
 
 	job := entities.VideoJob{
 		VideoName: "test-video.mp4",
@@ -125,7 +132,7 @@ func TestProcessVideoUseCase_Execute_PublisherError(t *testing.T) {
 
 	messageData, _ := json.Marshal(job)
 
-	err := useCase.Execute(messageData)
+	err := useCase.Execute(context.Background(), messageData, 0)
 	if err == nil {
 		t.Error("Expected error from publisher")
 	}
@@ -145,7 +152,7 @@ func TestProcessVideoUseCase_Execute_CorrectQueueName(t *testing.T) {
 		},
 	}
 
-	useCase := NewProcessVideoUseCase(processor, publisher)
+	useCase := NewProcessVideoUseCase(processor, publisher, 3)
 
 	job := entities.VideoJob{
 		VideoName: "test-video.mp4",
@@ -155,7 +162,7 @@ func TestProcessVideoUseCase_Execute_CorrectQueueName(t *testing.T) {
 
 	messageData, _ := json.Marshal(job)
 
-	err := useCase.Execute(messageData)
+	err := useCase.Execute(context.Background(), messageData, 0)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -178,7 +185,7 @@ func TestProcessVideoUseCase_Execute_ProcessorReceivesCorrectJob(t *testing.T) {
 		},
 	}
 	publisher := &MockPublisher{}
-	useCase := NewProcessVideoUseCase(processor, publisher)
+	useCase := NewProcessVideoUseCase(processor, publisher, 3)
 
 	expectedJob := entities.VideoJob{
 		VideoName: "test-video.mp4",
@@ -188,7 +195,7 @@ func TestProcessVideoUseCase_Execute_ProcessorReceivesCorrectJob(t *testing.T) {
 
 	messageData, _ := json.Marshal(expectedJob)
 
-	err := useCase.Execute(messageData)
+	err := useCase.Execute(context.Background(), messageData, 0)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -206,3 +213,111 @@ func TestProcessVideoUseCase_Execute_ProcessorReceivesCorrectJob(t *testing.T) {
 		t.Errorf("Expected JobId '%s', got '%s'", expectedJob.JobId, capturedJob.JobId)
 	}
 }
+
+func TestProcessVideoUseCase_Execute_InvalidOptions(t *testing.T) {
+	processorCalled := false
+	processor := &MockVideoProcessor{
+		processVideoFunc: func(job *entities.VideoJob) (*entities.ProcessingResult, error) {
+			processorCalled = true
+			return nil, nil
+		},
+	}
+	publisher := &MockPublisher{}
+	useCase := NewProcessVideoUseCase(processor, publisher, 3)
+
+	messageData := []byte(`{"videoName":"v.mp4","VideoPath":"/v.mp4","jobId":"job-123","options":{"format":"gif"}}`)
+
+	err := useCase.Execute(context.Background(), messageData, 0)
+	if !customerrors.IsPermanentError(err) {
+		t.Errorf("Expected permanent error for invalid options, got %v", err)
+	}
+	if processorCalled {
+		t.Error("Expected processor not to be called for invalid options")
+	}
+}
+
+func TestProcessVideoUseCase_Execute_PublishesFailureResult(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		retryCount     int
+		expectedStatus string
+		expectedCode   string
+	}{
+		{"permanent error", fmt.Errorf("%w: missing.mp4", customerrors.ErrFileNotFound), 0, entities.StatusFailed, "FILE_NOT_FOUND"},
+		{"temporary error", fmt.Errorf("%w: timeout", customerrors.ErrStorageUnavailable), 1, entities.StatusRetrying, "STORAGE_UNAVAILABLE"},
+		{"retries exhausted", fmt.Errorf("%w: timeout", customerrors.ErrStorageUnavailable), 3, entities.StatusFailed, "STORAGE_UNAVAILABLE"},
+		{"unknown error", errors.New("boom"), 0, entities.StatusRetrying, customerrors.CodeInternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &MockVideoProcessor{
+				processVideoFunc: func(job *entities.VideoJob) (*entities.ProcessingResult, error) {
+					return &entities.ProcessingResult{Status: entities.StatusFailed, JobId: job.JobId}, tt.err
+				},
+			}
+
+			var published *entities.ProcessingResult
+			publisher := &MockPublisher{
+				publishFunc: func(queueName string, result *entities.ProcessingResult) error {
+					published = result
+					return nil
+				},
+			}
+			useCase := NewProcessVideoUseCase(processor, publisher, 3)
+
+			messageData := []byte(`{"videoName":"v.mp4","VideoPath":"/v.mp4","jobId":"job-123"}`)
+
+			err := useCase.Execute(context.Background(), messageData, tt.retryCount)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+
+			if published == nil {
+				t.Fatal("Expected a result to be published")
+			}
+			if published.Status != tt.expectedStatus {
+				t.Errorf("Expected Status '%s', got '%s'", tt.expectedStatus, published.Status)
+			}
+			if published.ErrorCode != tt.expectedCode {
+				t.Errorf("Expected ErrorCode '%s', got '%s'", tt.expectedCode, published.ErrorCode)
+			}
+			if published.ErrorMessage != tt.err.Error() {
+				t.Errorf("Expected ErrorMessage '%s', got '%s'", tt.err.Error(), published.ErrorMessage)
+			}
+			if published.JobId != "job-123" {
+				t.Errorf("Expected JobId 'job-123', got '%s'", published.JobId)
+			}
+		})
+	}
+}
+
+func TestProcessVideoUseCase_Execute_CancelledJobPublishesNothing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	processor := &MockVideoProcessor{
+		processVideoFunc: func(job *entities.VideoJob) (*entities.ProcessingResult, error) {
+			return nil, context.Canceled
+		},
+	}
+
+	publishCalled := false
+	publisher := &MockPublisher{
+		publishFunc: func(queueName string, result *entities.ProcessingResult) error {
+			publishCalled = true
+			return nil
+		},
+	}
+	useCase := NewProcessVideoUseCase(processor, publisher, 3)
+
+	messageData := []byte(`{"videoName":"v.mp4","VideoPath":"/v.mp4","jobId":"job-123"}`)
+
+	if err := useCase.Execute(ctx, messageData, 0); err == nil {
+		t.Error("Expected error for cancelled job")
+	}
+	if publishCalled {
+		t.Error("Expected no result to be published for a cancelled job")
+	}
+}
@@ -0,0 +1,123 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+const (
+	FormatJPG  = "jpg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+
+	ModeInterval  = "interval"
+	ModeScene     = "scene"
+	ModeKeyframes = "keyframes"
+
+	DefaultFPS            = 1.0
+	DefaultFormat         = FormatJPG
+	DefaultMode           = ModeInterval
+	DefaultSceneThreshold = 0.4
+
+	MaxFPS       = 60.0
+	MaxDimension = 7680
+)
+
+type ExtractionOptions struct {
+	Mode           string  `json:"mode,omitempty"`
+	FPS            float64 `json:"fps,omitempty"`
+	Format         string  `json:"format,omitempty"`
+	Quality        int     `json:"quality,omitempty"`
+	Width          int     `json:"width,omitempty"`
+	Height         int     `json:"height,omitempty"`
+	SceneThreshold float64 `json:"sceneThreshold,omitempty"`
+	MinFrames      int     `json:"minFrames,omitempty"`
+	MaxFrames      int     `json:"maxFrames,omitempty"`
+	ManifestCSV    bool    `json:"manifestCsv,omitempty"`
+}
+
+func DefaultExtractionOptions() ExtractionOptions {
+	return ExtractionOptions{
+		Mode:           DefaultMode,
+		FPS:            DefaultFPS,
+		Format:         DefaultFormat,
+		SceneThreshold: DefaultSceneThreshold,
+	}
+}
+
+func (o ExtractionOptions) WithDefaults() ExtractionOptions {
+	resolved := o
+
+	resolved.Mode = strings.ToLower(strings.TrimSpace(resolved.Mode))
+	if resolved.Mode == "" {
+		resolved.Mode = DefaultMode
+	}
+
+	if resolved.SceneThreshold == 0 {
+		resolved.SceneThreshold = DefaultSceneThreshold
+	}
+
+	if resolved.FPS == 0 {
+		resolved.FPS = DefaultFPS
+	}
+
+	resolved.Format = normalizeFormat(resolved.Format)
+	if resolved.Format == "" {
+		resolved.Format = DefaultFormat
+	}
+
+	return resolved
+}
+
+func (o ExtractionOptions) Validate() error {
+	switch strings.ToLower(strings.TrimSpace(o.Mode)) {
+	case "", ModeInterval, ModeScene, ModeKeyframes:
+	default:
+		return fmt.Errorf("%w: unsupported extraction mode %q", customerrors.ErrInvalidJobData, o.Mode)
+	}
+
+	if o.FPS < 0 || o.FPS > MaxFPS {
+		return fmt.Errorf("%w: fps must be between 0 and %g, got %g", customerrors.ErrInvalidJobData, MaxFPS, o.FPS)
+	}
+
+	switch normalizeFormat(o.Format) {
+	case "", FormatJPG, FormatPNG, FormatWebP:
+	default:
+		return fmt.Errorf("%w: unsupported output format %q", customerrors.ErrInvalidJobData, o.Format)
+	}
+
+	if o.Quality < 0 || o.Quality > 100 {
+		return fmt.Errorf("%w: quality must be between 1 and 100, got %d", customerrors.ErrInvalidJobData, o.Quality)
+	}
+
+	if o.Width < 0 || o.Width > MaxDimension {
+		return fmt.Errorf("%w: width must be between 1 and %d, got %d", customerrors.ErrInvalidJobData, MaxDimension, o.Width)
+	}
+
+	if o.Height < 0 || o.Height > MaxDimension {
+		return fmt.Errorf("%w: height must be between 1 and %d, got %d", customerrors.ErrInvalidJobData, MaxDimension, o.Height)
+	}
+
+	if o.SceneThreshold < 0 || o.SceneThreshold > 1 {
+		return fmt.Errorf("%w: sceneThreshold must be between 0 and 1, got %g", customerrors.ErrInvalidJobData, o.SceneThreshold)
+	}
+
+	if o.MinFrames < 0 || o.MaxFrames < 0 {
+		return fmt.Errorf("%w: minFrames and maxFrames must not be negative", customerrors.ErrInvalidJobData)
+	}
+
+	if o.MaxFrames > 0 && o.MinFrames > o.MaxFrames {
+		return fmt.Errorf("%w: minFrames (%d) is greater than maxFrames (%d)", customerrors.ErrInvalidJobData, o.MinFrames, o.MaxFrames)
+	}
+
+	return nil
+}
+
+func normalizeFormat(format string) string {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "jpeg" {
+		return FormatJPG
+	}
+	return format
+}
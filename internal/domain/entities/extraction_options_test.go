@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+func TestExtractionOptions_WithDefaults(t *testing.T) {
+	resolved := ExtractionOptions{}.WithDefaults()
+
+	if resolved.FPS != DefaultFPS {
+		t.Errorf("Expected FPS %g, got %g", DefaultFPS, resolved.FPS)
+	}
+	if resolved.Format != DefaultFormat {
+		t.Errorf("Expected Format '%s', got '%s'", DefaultFormat, resolved.Format)
+	}
+	if resolved.Mode != DefaultMode {
+		t.Errorf("Expected Mode '%s', got '%s'", DefaultMode, resolved.Mode)
+	}
+	if resolved.SceneThreshold != DefaultSceneThreshold {
+		t.Errorf("Expected SceneThreshold %g, got %g", DefaultSceneThreshold, resolved.SceneThreshold)
+	}
+}
+
+func TestExtractionOptions_WithDefaults_NormalizesFormat(t *testing.T) {
+	resolved := ExtractionOptions{Format: " JPEG "}.WithDefaults()
+
+	if resolved.Format != FormatJPG {
+		t.Errorf("Expected Format '%s', got '%s'", FormatJPG, resolved.Format)
+	}
+}
+
+func TestExtractionOptions_Validate_Valid(t *testing.T) {
+	options := []ExtractionOptions{
+		{},
+		{FPS: 0.5, Format: "png"},
+		{FPS: 30, Format: "webp", Quality: 80, Width: 1280},
+		{Format: "JPG", Quality: 100, Width: 640, Height: 480},
+		{Mode: "Scene", SceneThreshold: 0.3, MinFrames: 5, MaxFrames: 50},
+		{Mode: ModeScene, MaxFrames: 10},
+		{Mode: ModeKeyframes, Width: 320, MaxFrames: 100},
+	}
+
+	for _, opts := range options {
+		if err := opts.Validate(); err != nil {
+			t.Errorf("Expected options %+v to be valid, got %v", opts, err)
+		}
+	}
+}
+
+func TestExtractionOptions_Validate_Invalid(t *testing.T) {
+	options := []ExtractionOptions{
+		{FPS: -1},
+		{FPS: MaxFPS + 1},
+		{Format: "gif"},
+		{Quality: -5},
+		{Quality: 101},
+		{Width: -1},
+		{Height: MaxDimension + 1},
+		{Mode: "random"},
+		{Mode: ModeScene, SceneThreshold: 1.5},
+		{Mode: ModeScene, SceneThreshold: -0.1},
+		{MinFrames: -1},
+		{MinFrames: 10, MaxFrames: 5},
+	}
+
+	for _, opts := range options {
+		err := opts.Validate()
+		if err == nil {
+			t.Errorf("Expected options %+v to be invalid", opts)
+			continue
+		}
+		if !errors.Is(err, customerrors.ErrInvalidJobData) {
+			t.Errorf("Expected ErrInvalidJobData for %+v, got %v", opts, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+const (
+	PreviewFormatGIF  = "gif"
+	PreviewFormatWebP = "webp"
+
+	DefaultPreviewFormat   = PreviewFormatGIF
+	DefaultPreviewDuration = 5.0
+	DefaultPreviewFPS      = 10.0
+	DefaultPreviewWidth    = 320
+
+	MaxPreviewDuration = 30.0
+	MaxPreviewFPS      = 30.0
+	MaxPreviewWidth    = 1280
+)
+
+// PreviewOptions configures the animated preview cut from Duration seconds
+// of the video starting at Start.
+type PreviewOptions struct {
+	Format   string  `json:"format,omitempty"`
+	Start    float64 `json:"start,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+	FPS      float64 `json:"fps,omitempty"`
+	Width    int     `json:"width,omitempty"`
+}
+
+func (o PreviewOptions) WithDefaults() PreviewOptions {
+	resolved := o
+
+	resolved.Format = strings.ToLower(strings.TrimSpace(resolved.Format))
+	if resolved.Format == "" {
+		resolved.Format = DefaultPreviewFormat
+	}
+	if resolved.Duration == 0 {
+		resolved.Duration = DefaultPreviewDuration
+	}
+	if resolved.FPS == 0 {
+		resolved.FPS = DefaultPreviewFPS
+	}
+	if resolved.Width == 0 {
+		resolved.Width = DefaultPreviewWidth
+	}
+
+	return resolved
+}
+
+func (o PreviewOptions) Validate() error {
+	switch strings.ToLower(strings.TrimSpace(o.Format)) {
+	case "", PreviewFormatGIF, PreviewFormatWebP:
+	default:
+		return fmt.Errorf("%w: unsupported preview format %q", customerrors.ErrInvalidJobData, o.Format)
+	}
+
+	if o.Start < 0 {
+		return fmt.Errorf("%w: preview start must not be negative, got %g", customerrors.ErrInvalidJobData, o.Start)
+	}
+
+	if o.Duration < 0 || o.Duration > MaxPreviewDuration {
+		return fmt.Errorf("%w: preview duration must be between 0 and %g, got %g", customerrors.ErrInvalidJobData, MaxPreviewDuration, o.Duration)
+	}
+
+	if o.FPS < 0 || o.FPS > MaxPreviewFPS {
+		return fmt.Errorf("%w: preview fps must be between 0 and %g, got %g", customerrors.ErrInvalidJobData, MaxPreviewFPS, o.FPS)
+	}
+
+	if o.Width < 0 || o.Width > MaxPreviewWidth {
+		return fmt.Errorf("%w: preview width must be between 1 and %d, got %d", customerrors.ErrInvalidJobData, MaxPreviewWidth, o.Width)
+	}
+
+	return nil
+}
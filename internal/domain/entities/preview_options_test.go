@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+func TestPreviewOptions_WithDefaults(t *testing.T) {
+	resolved := PreviewOptions{Format: " WEBP ", Start: 12}.WithDefaults()
+
+	expected := PreviewOptions{
+		Format:   PreviewFormatWebP,
+		Start:    12,
+		Duration: DefaultPreviewDuration,
+		FPS:      DefaultPreviewFPS,
+		Width:    DefaultPreviewWidth,
+	}
+	if resolved != expected {
+		t.Errorf("Expected %+v, got %+v", expected, resolved)
+	}
+}
+
+func TestPreviewOptions_Validate(t *testing.T) {
+	valid := []PreviewOptions{
+		{},
+		{Format: "gif", Start: 30, Duration: 3, FPS: 12, Width: 480},
+		{Format: "WebP", Duration: MaxPreviewDuration},
+	}
+
+	for _, opts := range valid {
+		if err := opts.Validate(); err != nil {
+			t.Errorf("Expected options %+v to be valid, got %v", opts, err)
+		}
+	}
+
+	invalid := []PreviewOptions{
+		{Format: "mp4"},
+		{Start: -1},
+		{Duration: MaxPreviewDuration + 1},
+		{FPS: MaxPreviewFPS + 1},
+		{Width: MaxPreviewWidth + 1},
+	}
+
+	for _, opts := range invalid {
+		err := opts.Validate()
+		if err == nil {
+			t.Errorf("Expected options %+v to be invalid", opts)
+			continue
+		}
+		if !errors.Is(err, customerrors.ErrInvalidJobData) {
+			t.Errorf("Expected ErrInvalidJobData for %+v, got %v", opts, err)
+		}
+	}
+}
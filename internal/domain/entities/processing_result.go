@@ -1,7 +1,81 @@
 package entities
 
+import "time"
+
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusRetrying  = "retrying"
+)
+
 type ProcessingResult struct {
-	Status     string
-	OutputPath string
-	JobId      string
+	Status       string
+	OutputPath   string
+	JobId        string
+	FrameCount   int              `json:",omitempty"`
+	Frames       []Frame          `json:",omitempty"`
+	Video        *VideoMetadata   `json:",omitempty"`
+	Archive      *ArchiveMetadata `json:",omitempty"`
+	Sprites      *SpriteMetadata  `json:",omitempty"`
+	Preview      *PreviewMetadata `json:",omitempty"`
+	FrameObjects *FrameObjects    `json:",omitempty"`
+	// OutputKey is the storage key of OutputPath, for signing new URLs to it
+	// later. DownloadURL is a presigned URL for it, when the storage
+	// generates one.
+	OutputKey            string     `json:",omitempty"`
+	DownloadURL          string     `json:",omitempty"`
+	DownloadURLExpiresAt *time.Time `json:",omitempty"`
+	StartedAt            *time.Time `json:",omitempty"`
+	FinishedAt           *time.Time `json:",omitempty"`
+	WorkerId             string     `json:",omitempty"`
+	ErrorCode            string     `json:",omitempty"`
+	ErrorMessage         string     `json:",omitempty"`
+}
+
+type Frame struct {
+	Index     int
+	File      string
+	Timestamp float64
+	Width     int    `json:",omitempty"`
+	Height    int    `json:",omitempty"`
+	Size      int64  `json:",omitempty"`
+	Checksum  string `json:",omitempty"`
+}
+
+type VideoMetadata struct {
+	Duration float64
+	Width    int
+	Height   int
+	Codec    string
+	Size     int64
+}
+
+type ArchiveMetadata struct {
+	Size        int64
+	Checksum    string
+	Format      string `json:",omitempty"`
+	ContentType string `json:",omitempty"`
+}
+
+type SpriteMetadata struct {
+	TrackURL   string
+	SheetURLs  []string
+	Columns    int
+	Rows       int
+	TileWidth  int
+	TileHeight int
+}
+
+type PreviewMetadata struct {
+	URL      string
+	Format   string
+	Size     int64
+	Checksum string
+}
+
+// FrameObjects locates frames uploaded as individual objects.
+type FrameObjects struct {
+	Prefix      string
+	ManifestURL string
+	Count       int
 }
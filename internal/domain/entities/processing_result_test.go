@@ -3,6 +3,7 @@ package entities
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestProcessingResult_Creation(t *testing.T) {
@@ -105,3 +106,62 @@ func TestProcessingResult_StatusVariations(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessingResult_JSONRoundTrip_WithMetadata(t *testing.T) {
+	startedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	finishedAt := startedAt.Add(90 * time.Second)
+
+	result := ProcessingResult{
+		Status:     "completed",
+		OutputPath: "https://bucket.s3.amazonaws.com/results/frames_job-1.zip",
+		OutputKey:  "results/frames_job-1.zip",
+		JobId:      "job-1",
+		FrameCount: 120,
+		Video: &VideoMetadata{
+			Duration: 120.5,
+			Width:    1920,
+			Height:   1080,
+			Codec:    "h264",
+			Size:     1048576,
+		},
+		Archive: &ArchiveMetadata{
+			Size:     524288,
+			Checksum: "abc123",
+		},
+		StartedAt:  &startedAt,
+		FinishedAt: &finishedAt,
+		WorkerId:   "worker-1",
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Failed to marshal ProcessingResult: %v", err)
+	}
+
+	var unmarshaled ProcessingResult
+	if err := json.Unmarshal(jsonData, &unmarshaled); err != nil {
+		t.Fatalf("Failed to unmarshal ProcessingResult: %v", err)
+	}
+
+	if unmarshaled.OutputKey != result.OutputKey {
+		t.Errorf("Expected OutputKey '%s', got '%s'", result.OutputKey, unmarshaled.OutputKey)
+	}
+	if unmarshaled.FrameCount != 120 {
+		t.Errorf("Expected FrameCount 120, got %d", unmarshaled.FrameCount)
+	}
+	if unmarshaled.Video == nil || *unmarshaled.Video != *result.Video {
+		t.Errorf("Expected Video %+v, got %+v", result.Video, unmarshaled.Video)
+	}
+	if unmarshaled.Archive == nil || *unmarshaled.Archive != *result.Archive {
+		t.Errorf("Expected Archive %+v, got %+v", result.Archive, unmarshaled.Archive)
+	}
+	if unmarshaled.StartedAt == nil || !unmarshaled.StartedAt.Equal(startedAt) {
+		t.Errorf("Expected StartedAt %v, got %v", startedAt, unmarshaled.StartedAt)
+	}
+	if unmarshaled.FinishedAt == nil || !unmarshaled.FinishedAt.Equal(finishedAt) {
+		t.Errorf("Expected FinishedAt %v, got %v", finishedAt, unmarshaled.FinishedAt)
+	}
+	if unmarshaled.WorkerId != "worker-1" {
+		t.Errorf("Expected WorkerId 'worker-1', got '%s'", unmarshaled.WorkerId)
+	}
+}
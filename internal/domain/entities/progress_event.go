@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+type ProgressEvent struct {
+	JobId      string
+	Percent    float64
+	Frames     int
+	ETASeconds float64
+	Timestamp  time.Time
+	WorkerId   string
+}
@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"fmt"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+const (
+	DefaultSpriteColumns   = 10
+	DefaultSpriteRows      = 10
+	DefaultSpriteTileWidth = 160
+
+	MaxSpriteGrid      = 50
+	MaxSpriteTileWidth = 1920
+	// JPEG sheets can't be over 65535 pixels on either side.
+	MaxSpriteSheetDimension = 65500
+)
+
+// SpriteOptions configures the sprite sheets tiled from the extracted
+// frames. A TileHeight of zero keeps the frames' aspect ratio.
+type SpriteOptions struct {
+	Columns    int `json:"columns,omitempty"`
+	Rows       int `json:"rows,omitempty"`
+	TileWidth  int `json:"tileWidth,omitempty"`
+	TileHeight int `json:"tileHeight,omitempty"`
+}
+
+func (o SpriteOptions) WithDefaults() SpriteOptions {
+	resolved := o
+
+	if resolved.Columns == 0 {
+		resolved.Columns = DefaultSpriteColumns
+	}
+	if resolved.Rows == 0 {
+		resolved.Rows = DefaultSpriteRows
+	}
+	if resolved.TileWidth == 0 {
+		resolved.TileWidth = DefaultSpriteTileWidth
+	}
+
+	return resolved
+}
+
+func (o SpriteOptions) Validate() error {
+	if o.Columns < 0 || o.Columns > MaxSpriteGrid || o.Rows < 0 || o.Rows > MaxSpriteGrid {
+		return fmt.Errorf("%w: sprite columns and rows must be between 1 and %d, got %dx%d", customerrors.ErrInvalidJobData, MaxSpriteGrid, o.Columns, o.Rows)
+	}
+
+	if o.TileWidth < 0 || o.TileWidth > MaxSpriteTileWidth || o.TileHeight < 0 || o.TileHeight > MaxSpriteTileWidth {
+		return fmt.Errorf("%w: sprite tiles must be between 1 and %d pixels, got %dx%d", customerrors.ErrInvalidJobData, MaxSpriteTileWidth, o.TileWidth, o.TileHeight)
+	}
+
+	return o.WithDefaults().ValidateSheet()
+}
+
+// ValidateSheet checks that a sheet of resolved options fits in a JPEG. A
+// TileHeight of zero, still to be derived from the frames, isn't checked.
+func (o SpriteOptions) ValidateSheet() error {
+	width, height := o.Columns*o.TileWidth, o.Rows*o.TileHeight
+	if width > MaxSpriteSheetDimension || height > MaxSpriteSheetDimension {
+		return fmt.Errorf("%w: sprite sheets must be at most %d pixels on each side, got %dx%d", customerrors.ErrInvalidJobData, MaxSpriteSheetDimension, width, height)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+func TestSpriteOptions_WithDefaults(t *testing.T) {
+	resolved := SpriteOptions{TileHeight: 90}.WithDefaults()
+
+	expected := SpriteOptions{
+		Columns:    DefaultSpriteColumns,
+		Rows:       DefaultSpriteRows,
+		TileWidth:  DefaultSpriteTileWidth,
+		TileHeight: 90,
+	}
+	if resolved != expected {
+		t.Errorf("Expected %+v, got %+v", expected, resolved)
+	}
+}
+
+func TestSpriteOptions_Validate(t *testing.T) {
+	valid := []SpriteOptions{
+		{},
+		{Columns: 5, Rows: 4, TileWidth: 320},
+		{TileWidth: 160, TileHeight: 90},
+	}
+
+	for _, opts := range valid {
+		if err := opts.Validate(); err != nil {
+			t.Errorf("Expected options %+v to be valid, got %v", opts, err)
+		}
+	}
+
+	invalid := []SpriteOptions{
+		{Columns: -1},
+		{Rows: MaxSpriteGrid + 1},
+		{TileWidth: MaxSpriteTileWidth + 1},
+		{TileHeight: -10},
+		{Columns: MaxSpriteGrid, TileWidth: MaxSpriteTileWidth},
+		{Rows: 40, TileHeight: 1800},
+	}
+
+	for _, opts := range invalid {
+		err := opts.Validate()
+		if err == nil {
+			t.Errorf("Expected options %+v to be invalid", opts)
+			continue
+		}
+		if !errors.Is(err, customerrors.ErrInvalidJobData) {
+			t.Errorf("Expected ErrInvalidJobData for %+v, got %v", opts, err)
+		}
+	}
+}
+
+func TestSpriteOptions_ValidateSheet(t *testing.T) {
+	if err := (SpriteOptions{Columns: 34, Rows: 10, TileWidth: 1920, TileHeight: 1080}).ValidateSheet(); err != nil {
+		t.Errorf("Expected a 65280px wide sheet to be valid, got %v", err)
+	}
+
+	// a portrait video, with the tile height derived from its frames
+	err := SpriteOptions{Columns: 5, Rows: 40, TileWidth: 1080, TileHeight: 1920}.ValidateSheet()
+	if !errors.Is(err, customerrors.ErrInvalidJobData) {
+		t.Errorf("Expected ErrInvalidJobData for a 76800px tall sheet, got %v", err)
+	}
+}
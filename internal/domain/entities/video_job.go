@@ -1,7 +1,148 @@
 package entities
 
+import (
+	"fmt"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+const (
+	OutputZip     = "zip"
+	OutputSprite  = "sprite"
+	OutputPreview = "preview"
+	OutputFrames  = "frames"
+
+	ArchiveZip    = "zip"
+	ArchiveTar    = "tar"
+	ArchiveTarGz  = "tar.gz"
+	ArchiveTarZst = "tar.zst"
+
+	MaxTimestamps = 1000
+)
+
 type VideoJob struct {
-	VideoName string `json:"videoName"`
-	VideoPath string `json:"VideoPath"`
-	JobId     string `json:"jobId"`
+	VideoName string             `json:"videoName"`
+	VideoPath string             `json:"VideoPath"`
+	JobId     string             `json:"jobId"`
+	Options   *ExtractionOptions `json:"options,omitempty"`
+	// StartTime and EndTime limit extraction to a window of the video, in
+	// seconds. An EndTime of zero means the end of the video.
+	StartTime float64 `json:"startTime,omitempty"`
+	EndTime   float64 `json:"endTime,omitempty"`
+	// Timestamps requests one frame at each listed second instead of
+	// sampling the video.
+	Timestamps []float64 `json:"timestamps,omitempty"`
+	// Outputs lists what the job produces from the frames; a ZIP when empty.
+	Outputs []string        `json:"outputs,omitempty"`
+	Sprite  *SpriteOptions  `json:"sprite,omitempty"`
+	Preview *PreviewOptions `json:"preview,omitempty"`
+	// ArchiveFormat overrides the worker's default archive format.
+	ArchiveFormat string `json:"archiveFormat,omitempty"`
+}
+
+func (j *VideoJob) Validate() error {
+	if j.StartTime < 0 || j.EndTime < 0 {
+		return fmt.Errorf("%w: startTime and endTime must not be negative", customerrors.ErrInvalidJobData)
+	}
+
+	if j.EndTime > 0 && j.EndTime <= j.StartTime {
+		return fmt.Errorf("%w: endTime (%g) must be greater than startTime (%g)", customerrors.ErrInvalidJobData, j.EndTime, j.StartTime)
+	}
+
+	if len(j.Timestamps) > 0 {
+		if j.StartTime > 0 || j.EndTime > 0 {
+			return fmt.Errorf("%w: timestamps cannot be combined with startTime or endTime", customerrors.ErrInvalidJobData)
+		}
+
+		if len(j.Timestamps) > MaxTimestamps {
+			return fmt.Errorf("%w: at most %d timestamps are allowed, got %d", customerrors.ErrInvalidJobData, MaxTimestamps, len(j.Timestamps))
+		}
+
+		for _, timestamp := range j.Timestamps {
+			if timestamp < 0 {
+				return fmt.Errorf("%w: timestamps must not be negative, got %g", customerrors.ErrInvalidJobData, timestamp)
+			}
+		}
+
+		if mode := j.ResolvedOptions().Mode; mode != ModeInterval {
+			return fmt.Errorf("%w: timestamps cannot be combined with mode %q", customerrors.ErrInvalidJobData, mode)
+		}
+	}
+
+	for _, output := range j.Outputs {
+		switch output {
+		case OutputZip, OutputSprite, OutputPreview, OutputFrames:
+		default:
+			return fmt.Errorf("%w: unsupported output %q", customerrors.ErrInvalidJobData, output)
+		}
+	}
+
+	if j.ArchiveFormat != "" && !IsArchiveFormat(j.ArchiveFormat) {
+		return fmt.Errorf("%w: unsupported archive format %q", customerrors.ErrInvalidJobData, j.ArchiveFormat)
+	}
+
+	if j.Sprite != nil {
+		if err := j.Sprite.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if j.Preview != nil {
+		if err := j.Preview.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if j.Options == nil {
+		return nil
+	}
+	return j.Options.Validate()
+}
+
+func (j *VideoJob) HasOutput(output string) bool {
+	if len(j.Outputs) == 0 {
+		return output == OutputZip
+	}
+
+	for _, requested := range j.Outputs {
+		if requested == output {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsFrames reports whether any requested output is built from extracted
+// frames; a preview alone is cut straight from the video.
+func (j *VideoJob) NeedsFrames() bool {
+	return j.HasOutput(OutputZip) || j.HasOutput(OutputSprite) || j.HasOutput(OutputFrames)
+}
+
+func (j *VideoJob) ResolvedPreview() PreviewOptions {
+	if j.Preview == nil {
+		return PreviewOptions{}.WithDefaults()
+	}
+	return j.Preview.WithDefaults()
+}
+
+func (j *VideoJob) ResolvedSprite() SpriteOptions {
+	if j.Sprite == nil {
+		return SpriteOptions{}.WithDefaults()
+	}
+	return j.Sprite.WithDefaults()
+}
+
+func (j *VideoJob) ResolvedOptions() ExtractionOptions {
+	if j.Options == nil {
+		return DefaultExtractionOptions()
+	}
+	return j.Options.WithDefaults()
+}
+
+func IsArchiveFormat(format string) bool {
+	switch format {
+	case ArchiveZip, ArchiveTar, ArchiveTarGz, ArchiveTarZst:
+		return true
+	default:
+		return false
+	}
 }
@@ -2,7 +2,9 @@ package entities
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
+	customerrors "upframer-worker/internal/domain/errors"
 )
 
 func TestVideoJob_JSONMarshal(t *testing.T) {
@@ -85,3 +87,118 @@ func TestVideoJob_WithSpecialCharacters(t *testing.T) {
 		t.Errorf("JobId mismatch after marshal/unmarshal")
 	}
 }
+
+func TestVideoJob_JSONUnmarshal_WithOptions(t *testing.T) {
+	jsonData := `{"videoName":"v.mp4","VideoPath":"/v.mp4","jobId":"job-1","options":{"fps":2.5,"format":"png","width":320}}`
+
+	var job VideoJob
+	if err := json.Unmarshal([]byte(jsonData), &job); err != nil {
+		t.Fatalf("Failed to unmarshal VideoJob: %v", err)
+	}
+
+	if job.Options == nil {
+		t.Fatal("Expected options to be set")
+	}
+
+	resolved := job.ResolvedOptions()
+	if resolved.FPS != 2.5 {
+		t.Errorf("Expected FPS 2.5, got %g", resolved.FPS)
+	}
+	if resolved.Format != FormatPNG {
+		t.Errorf("Expected Format 'png', got '%s'", resolved.Format)
+	}
+	if resolved.Width != 320 {
+		t.Errorf("Expected Width 320, got %d", resolved.Width)
+	}
+}
+
+func TestVideoJob_ResolvedOptions_Default(t *testing.T) {
+	job := VideoJob{JobId: "job-1"}
+
+	if err := job.Validate(); err != nil {
+		t.Errorf("Expected job without options to be valid, got %v", err)
+	}
+	if job.ResolvedOptions() != DefaultExtractionOptions() {
+		t.Errorf("Expected default options, got %+v", job.ResolvedOptions())
+	}
+}
+
+func TestVideoJob_Validate_Selection(t *testing.T) {
+	valid := []VideoJob{
+		{StartTime: 10},
+		{StartTime: 10, EndTime: 20},
+		{EndTime: 5},
+		{Timestamps: []float64{0, 1.5, 30}},
+		{Timestamps: []float64{12}, Options: &ExtractionOptions{Mode: ModeInterval, Width: 320}},
+	}
+
+	for _, job := range valid {
+		if err := job.Validate(); err != nil {
+			t.Errorf("Expected job %+v to be valid, got %v", job, err)
+		}
+	}
+
+	invalid := []VideoJob{
+		{StartTime: -1},
+		{StartTime: 20, EndTime: 10},
+		{StartTime: 10, EndTime: 10},
+		{Timestamps: []float64{-2}},
+		{Timestamps: []float64{5}, StartTime: 1},
+		{Timestamps: []float64{5}, Options: &ExtractionOptions{Mode: ModeScene}},
+		{Timestamps: make([]float64, MaxTimestamps+1)},
+	}
+
+	for _, job := range invalid {
+		err := job.Validate()
+		if err == nil {
+			t.Errorf("Expected job %+v to be invalid", job)
+			continue
+		}
+		if !errors.Is(err, customerrors.ErrInvalidJobData) {
+			t.Errorf("Expected ErrInvalidJobData for %+v, got %v", job, err)
+		}
+	}
+}
+
+func TestVideoJob_Outputs(t *testing.T) {
+	job := VideoJob{}
+	if !job.HasOutput(OutputZip) || job.HasOutput(OutputSprite) {
+		t.Errorf("Expected a job without outputs to produce only a ZIP")
+	}
+
+	job = VideoJob{Outputs: []string{OutputSprite}}
+	if job.HasOutput(OutputZip) || !job.HasOutput(OutputSprite) {
+		t.Errorf("Expected outputs %v to produce only sprites", job.Outputs)
+	}
+	if err := job.Validate(); err != nil {
+		t.Errorf("Expected job to be valid, got %v", err)
+	}
+
+	job = VideoJob{Outputs: []string{OutputPreview}, Preview: &PreviewOptions{Format: "webp"}}
+	if job.NeedsFrames() {
+		t.Errorf("Expected a preview-only job not to need frames")
+	}
+	if err := job.Validate(); err != nil {
+		t.Errorf("Expected job to be valid, got %v", err)
+	}
+
+	job = VideoJob{Outputs: []string{OutputFrames}}
+	if !job.NeedsFrames() || job.HasOutput(OutputZip) {
+		t.Errorf("Expected outputs %v to need frames without a ZIP", job.Outputs)
+	}
+
+	job = VideoJob{ArchiveFormat: ArchiveTarZst}
+	if err := job.Validate(); err != nil {
+		t.Errorf("Expected job to be valid, got %v", err)
+	}
+
+	job = VideoJob{ArchiveFormat: "rar"}
+	if err := job.Validate(); !errors.Is(err, customerrors.ErrInvalidJobData) {
+		t.Errorf("Expected ErrInvalidJobData for unknown archive format, got %v", err)
+	}
+
+	job = VideoJob{Outputs: []string{"mp4"}}
+	if err := job.Validate(); !errors.Is(err, customerrors.ErrInvalidJobData) {
+		t.Errorf("Expected ErrInvalidJobData for unknown output, got %v", err)
+	}
+}
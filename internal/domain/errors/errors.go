@@ -7,21 +7,103 @@ var (
 	ErrInvalidURLFormat     = errors.New("invalid URL format")
 	ErrInvalidMessageFormat = errors.New("invalid message format")
 	ErrInvalidJobData       = errors.New("invalid job data")
+	ErrUnsupportedVideo     = errors.New("unsupported or invalid video")
+	ErrInvalidVideoData     = errors.New("invalid data found in video")
+	ErrCorruptContainer     = errors.New("corrupt or incomplete video container")
+	ErrUnsupportedCodec     = errors.New("unsupported video codec")
 
 	ErrStorageUnavailable = errors.New("storage temporarily unavailable")
 	ErrNetworkTimeout     = errors.New("network timeout")
 	ErrFFmpegProcessing   = errors.New("ffmpeg processing error")
+	ErrMessagePublishing  = errors.New("message publishing failed")
+	ErrNoSpaceLeft        = errors.New("no space left on device")
+	ErrFFmpegKilled       = errors.New("ffmpeg killed by signal")
+	ErrProcessingTimeout  = errors.New("job processing timed out")
 )
 
+const CodeInternalError = "INTERNAL_ERROR"
+
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrFileNotFound, "FILE_NOT_FOUND"},
+	{ErrInvalidURLFormat, "INVALID_URL_FORMAT"},
+	{ErrInvalidMessageFormat, "INVALID_MESSAGE_FORMAT"},
+	{ErrInvalidJobData, "INVALID_JOB_DATA"},
+	{ErrUnsupportedVideo, "UNSUPPORTED_VIDEO"},
+	{ErrInvalidVideoData, "INVALID_VIDEO_DATA"},
+	{ErrCorruptContainer, "CORRUPT_CONTAINER"},
+	{ErrUnsupportedCodec, "UNSUPPORTED_CODEC"},
+	{ErrStorageUnavailable, "STORAGE_UNAVAILABLE"},
+	{ErrNetworkTimeout, "NETWORK_TIMEOUT"},
+	{ErrFFmpegProcessing, "FFMPEG_PROCESSING_ERROR"},
+	{ErrMessagePublishing, "MESSAGE_PUBLISHING_FAILED"},
+	{ErrNoSpaceLeft, "NO_SPACE_LEFT"},
+	{ErrFFmpegKilled, "FFMPEG_KILLED"},
+	{ErrProcessingTimeout, "PROCESSING_TIMEOUT"},
+}
+
+// DetailedError attaches diagnostic output, such as an ffmpeg stderr excerpt,
+// to an error without changing its message or classification.
+type DetailedError struct {
+	Err    error
+	Detail string
+}
+
+func (e *DetailedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DetailedError) Unwrap() error {
+	return e.Err
+}
+
+func WithDetail(err error, detail string) error {
+	if err == nil || detail == "" {
+		return err
+	}
+	return &DetailedError{Err: err, Detail: detail}
+}
+
+func Detail(err error) string {
+	var detailed *DetailedError
+	if errors.As(err, &detailed) {
+		return detailed.Detail
+	}
+	return ""
+}
+
 func IsPermanentError(err error) bool {
 	return errors.Is(err, ErrFileNotFound) ||
 		errors.Is(err, ErrInvalidURLFormat) ||
 		errors.Is(err, ErrInvalidMessageFormat) ||
-		errors.Is(err, ErrInvalidJobData)
+		errors.Is(err, ErrInvalidJobData) ||
+		errors.Is(err, ErrUnsupportedVideo) ||
+		errors.Is(err, ErrInvalidVideoData) ||
+		errors.Is(err, ErrCorruptContainer) ||
+		errors.Is(err, ErrUnsupportedCodec)
 }
 
 func IsTemporaryError(err error) bool {
 	return errors.Is(err, ErrStorageUnavailable) ||
 		errors.Is(err, ErrNetworkTimeout) ||
-		errors.Is(err, ErrFFmpegProcessing)
+		errors.Is(err, ErrFFmpegProcessing) ||
+		errors.Is(err, ErrMessagePublishing) ||
+		errors.Is(err, ErrNoSpaceLeft) ||
+		errors.Is(err, ErrFFmpegKilled) ||
+		IsTimeoutError(err)
+}
+
+func IsTimeoutError(err error) bool {
+	return errors.Is(err, ErrProcessingTimeout)
+}
+
+func ErrorCode(err error) string {
+	for _, entry := range errorCodes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	return CodeInternalError
 }
@@ -0,0 +1,67 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCode_Sentinels(t *testing.T) {
+	for _, entry := range errorCodes {
+		wrapped := fmt.Errorf("%w: details", entry.err)
+
+		if code := ErrorCode(wrapped); code != entry.code {
+			t.Errorf("Expected code '%s' for %v, got '%s'", entry.code, entry.err, code)
+		}
+	}
+}
+
+func TestErrorCode_Unknown(t *testing.T) {
+	if code := ErrorCode(errors.New("boom")); code != CodeInternalError {
+		t.Errorf("Expected code '%s', got '%s'", CodeInternalError, code)
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	for _, entry := range errorCodes {
+		if IsPermanentError(entry.err) && IsTemporaryError(entry.err) {
+			t.Errorf("Expected %v to be either permanent or temporary, not both", entry.err)
+		}
+		if !IsPermanentError(entry.err) && !IsTemporaryError(entry.err) {
+			t.Errorf("Expected %v to be classified as permanent or temporary", entry.err)
+		}
+	}
+}
+
+func TestWithDetail(t *testing.T) {
+	err := WithDetail(fmt.Errorf("%w: exit status 1", ErrCorruptContainer), "moov atom not found")
+
+	if !IsPermanentError(err) {
+		t.Error("Expected detailed error to keep its classification")
+	}
+	if ErrorCode(err) != "CORRUPT_CONTAINER" {
+		t.Errorf("Expected code 'CORRUPT_CONTAINER', got '%s'", ErrorCode(err))
+	}
+	if err.Error() != "corrupt or incomplete video container: exit status 1" {
+		t.Errorf("Expected message to be unchanged, got '%s'", err.Error())
+	}
+
+	wrapped := fmt.Errorf("processing job: %w", err)
+	if Detail(wrapped) != "moov atom not found" {
+		t.Errorf("Expected detail 'moov atom not found', got '%s'", Detail(wrapped))
+	}
+}
+
+func TestWithDetail_Empty(t *testing.T) {
+	base := errors.New("boom")
+
+	if WithDetail(base, "") != base {
+		t.Error("Expected error without detail to be returned unchanged")
+	}
+	if WithDetail(nil, "detail") != nil {
+		t.Error("Expected nil error to stay nil")
+	}
+	if Detail(base) != "" {
+		t.Errorf("Expected empty detail, got '%s'", Detail(base))
+	}
+}
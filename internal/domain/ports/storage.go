@@ -1,11 +1,41 @@
 package ports
 
+import (
+	"context"
+	"time"
+)
+
+// StorageResult describes a stored artifact. Path is its permanent key; the
+// presigned URL, when the adapter generates one, grants temporary access
+// until PresignedURLExpiresAt.
 type StorageResult struct {
-	Path string
-	URL  string
+	Path                  string
+	URL                   string
+	Size                  int64
+	Checksum              string
+	ContentType           string
+	PresignedURL          string
+	PresignedURLExpiresAt *time.Time
+}
+
+// DirectoryResult describes a directory uploaded file by file. Files is keyed
+// by each file's path relative to the uploaded directory.
+type DirectoryResult struct {
+	Prefix string
+	Files  map[string]*StorageResult
 }
 
 type Storage interface {
-	StoreZip(sourceDir, zipFileName string) (*StorageResult, error)
-	Download(path, localPath string) error
+	StoreArchive(ctx context.Context, sourceDir, fileName, format string) (*StorageResult, error)
+	StoreFile(ctx context.Context, localPath, name, contentType string) (*StorageResult, error)
+	// UploadDirectory uploads every file under sourceDir below prefix. The
+	// files named in last, such as a manifest listing the others, are only
+	// uploaded once everything else is, in the given order, and are the only
+	// ones given a presigned URL. On failure the objects already uploaded are
+	// deleted.
+	UploadDirectory(ctx context.Context, sourceDir, prefix string, last ...string) (*DirectoryResult, error)
+	// ObjectSize returns the size in bytes of the object at path, so the disk
+	// for it can be reserved before downloading.
+	ObjectSize(ctx context.Context, path string) (int64, error)
+	Download(ctx context.Context, path, localPath string) error
 }
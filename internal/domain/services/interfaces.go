@@ -1,11 +1,15 @@
 package services
 
-import "upframer-worker/internal/domain/entities"
+import (
+	"context"
+	"upframer-worker/internal/domain/entities"
+)
 
 type VideoProcessor interface {
-	ProcessVideo(job *entities.VideoJob) (*entities.ProcessingResult, error)
+	ProcessVideo(ctx context.Context, job *entities.VideoJob) (*entities.ProcessingResult, error)
 }
 
 type Publisher interface {
 	Publish(queueName string, result *entities.ProcessingResult) error
+	PublishProgress(queueName string, event *entities.ProgressEvent) error
 }
@@ -0,0 +1,123 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+const (
+	maxStderrLines   = 20
+	maxExcerptLength = 1024
+)
+
+// stderrPatterns is ordered by priority: environmental causes come first, as
+// ffmpeg logs bad packets and carries on, so an earlier bad-input line
+// doesn't explain why it finally failed.
+var stderrPatterns = []struct {
+	pattern string
+	err     error
+}{
+	{"no space left on device", customerrors.ErrNoSpaceLeft},
+	{"moov atom not found", customerrors.ErrCorruptContainer},
+	{"invalid data found when processing input", customerrors.ErrInvalidVideoData},
+	{"decoder not found", customerrors.ErrUnsupportedCodec},
+	{"unsupported codec", customerrors.ErrUnsupportedCodec},
+	{"could not find codec parameters", customerrors.ErrUnsupportedCodec},
+	{"no such file or directory", customerrors.ErrFileNotFound},
+	{"server returned 404 not found", customerrors.ErrFileNotFound},
+}
+
+// stderrCollector keeps the last lines written by ffmpeg and remembers the
+// highest-priority failure pattern matched, however early it appeared.
+type stderrCollector struct {
+	partial     []byte
+	lines       []string
+	matched     error
+	matchedRank int
+	onLine      func(string) bool
+}
+
+func (c *stderrCollector) Write(p []byte) (int, error) {
+	c.partial = append(c.partial, p...)
+
+	for {
+		i := bytes.IndexAny(c.partial, "\r\n")
+		if i < 0 {
+			break
+		}
+		c.addLine(string(c.partial[:i]))
+		c.partial = c.partial[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (c *stderrCollector) flush() {
+	if len(c.partial) > 0 {
+		c.addLine(string(c.partial))
+		c.partial = nil
+	}
+}
+
+func (c *stderrCollector) addLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	if c.onLine != nil && c.onLine(line) {
+		return
+	}
+
+	lower := strings.ToLower(line)
+	for rank, entry := range stderrPatterns {
+		if c.matched != nil && rank >= c.matchedRank {
+			break
+		}
+		if strings.Contains(lower, entry.pattern) {
+			c.matched = entry.err
+			c.matchedRank = rank
+			break
+		}
+	}
+
+	c.lines = append(c.lines, line)
+	if len(c.lines) > maxStderrLines {
+		c.lines = c.lines[len(c.lines)-maxStderrLines:]
+	}
+}
+
+func (c *stderrCollector) excerpt() string {
+	excerpt := strings.Join(c.lines, "\n")
+	if len(excerpt) > maxExcerptLength {
+		excerpt = "..." + excerpt[len(excerpt)-maxExcerptLength:]
+	}
+	return excerpt
+}
+
+// classifyFFmpegError lets a temporary cause seen on stderr win, then a kill
+// by signal, and only then a bad-input pattern, so a video with a damaged
+// packet isn't failed permanently when ffmpeg actually ran out of disk or
+// memory.
+func classifyFFmpegError(runErr error, stderr *stderrCollector) error {
+	var exitErr *exec.ExitError
+	killed := errors.As(runErr, &exitErr) && exitErr.ExitCode() == -1
+
+	var sentinel error
+	switch {
+	case stderr.matched != nil && customerrors.IsTemporaryError(stderr.matched):
+		sentinel = stderr.matched
+	case killed:
+		sentinel = customerrors.ErrFFmpegKilled
+	case stderr.matched != nil:
+		sentinel = stderr.matched
+	default:
+		sentinel = customerrors.ErrFFmpegProcessing
+	}
+
+	return customerrors.WithDetail(fmt.Errorf("%w: %v", sentinel, runErr), stderr.excerpt())
+}
@@ -0,0 +1,126 @@
+package ffmpeg
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+func runShell(t *testing.T, script string) error {
+	t.Helper()
+
+	err := exec.Command("sh", "-c", script).Run()
+	if err == nil {
+		t.Fatalf("Expected %q to fail", script)
+	}
+	return err
+}
+
+func TestStderrCollector_Patterns(t *testing.T) {
+	for _, entry := range stderrPatterns {
+		stderr := &stderrCollector{}
+		stderr.Write([]byte("[in#0 @ 0x55d0] Opening input\n[mov,mp4 @ 0x55d0] " + strings.ToUpper(entry.pattern) + "\n"))
+		stderr.flush()
+
+		if stderr.matched != entry.err {
+			t.Errorf("Expected %q to match %v, got %v", entry.pattern, entry.err, stderr.matched)
+		}
+	}
+}
+
+func TestStderrCollector_KeepsHighestPriorityMatch(t *testing.T) {
+	stderr := &stderrCollector{}
+	stderr.Write([]byte("Error while decoding stream #0:0: Invalid data found when processing input\n"))
+	stderr.Write([]byte("av_interleaved_write_frame(): No space left on device\n"))
+	stderr.Write([]byte("moov atom not found\n"))
+
+	if stderr.matched != customerrors.ErrNoSpaceLeft {
+		t.Errorf("Expected ErrNoSpaceLeft to win over earlier bad-input lines, got %v", stderr.matched)
+	}
+}
+
+func TestStderrCollector_PartialLastLine(t *testing.T) {
+	stderr := &stderrCollector{}
+	stderr.Write([]byte("frame=  10\rframe=  20\r\nInvalid data found "))
+	stderr.Write([]byte("when processing input"))
+
+	if stderr.matched != nil {
+		t.Errorf("Expected no match before the line is complete, got %v", stderr.matched)
+	}
+
+	stderr.flush()
+
+	if stderr.matched != customerrors.ErrInvalidVideoData {
+		t.Errorf("Expected ErrInvalidVideoData after flush, got %v", stderr.matched)
+	}
+	if expected := "frame=  10\nframe=  20\nInvalid data found when processing input"; stderr.excerpt() != expected {
+		t.Errorf("Expected excerpt %q, got %q", expected, stderr.excerpt())
+	}
+}
+
+func TestStderrCollector_OnLineConsumesLines(t *testing.T) {
+	var seen []string
+	stderr := &stderrCollector{onLine: func(line string) bool {
+		seen = append(seen, line)
+		return strings.HasPrefix(line, "[Parsed_showinfo")
+	}}
+	stderr.Write([]byte("[Parsed_showinfo_1 @ 0x1] n:0 pts_time:0\nreal error\n"))
+
+	if len(seen) != 2 {
+		t.Errorf("Expected the hook to see 2 lines, got %d", len(seen))
+	}
+	if stderr.excerpt() != "real error" {
+		t.Errorf("Expected consumed lines to be left out of the excerpt, got %q", stderr.excerpt())
+	}
+}
+
+func TestStderrCollector_Excerpt(t *testing.T) {
+	stderr := &stderrCollector{}
+	for i := 0; i < maxStderrLines+5; i++ {
+		stderr.Write([]byte(strings.Repeat("x", 100) + "\n"))
+	}
+
+	if len(stderr.lines) != maxStderrLines {
+		t.Errorf("Expected %d lines kept, got %d", maxStderrLines, len(stderr.lines))
+	}
+
+	excerpt := stderr.excerpt()
+	if len(excerpt) != maxExcerptLength+len("...") || !strings.HasPrefix(excerpt, "...") {
+		t.Errorf("Expected excerpt truncated to %d bytes, got %d", maxExcerptLength, len(excerpt))
+	}
+}
+
+func TestClassifyFFmpegError(t *testing.T) {
+	matched := &stderrCollector{}
+	matched.Write([]byte("moov atom not found\n"))
+
+	noSpace := &stderrCollector{}
+	noSpace.Write([]byte("Invalid data found when processing input\nNo space left on device\n"))
+
+	tests := []struct {
+		name     string
+		runErr   error
+		stderr   *stderrCollector
+		expected error
+	}{
+		{"matched pattern", runShell(t, "exit 1"), matched, customerrors.ErrCorruptContainer},
+		{"unknown failure", runShell(t, "exit 1"), &stderrCollector{}, customerrors.ErrFFmpegProcessing},
+		{"killed by signal", runShell(t, "kill -9 $$"), &stderrCollector{}, customerrors.ErrFFmpegKilled},
+		{"signal wins over bad input", runShell(t, "kill -9 $$"), matched, customerrors.ErrFFmpegKilled},
+		{"no space wins over bad input", runShell(t, "exit 1"), noSpace, customerrors.ErrNoSpaceLeft},
+		{"no space wins over signal", runShell(t, "kill -9 $$"), noSpace, customerrors.ErrNoSpaceLeft},
+	}
+
+	for _, tt := range tests {
+		err := classifyFFmpegError(tt.runErr, tt.stderr)
+
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+		if customerrors.Detail(err) != tt.stderr.excerpt() {
+			t.Errorf("%s: expected detail %q, got %q", tt.name, tt.stderr.excerpt(), customerrors.Detail(err))
+		}
+	}
+}
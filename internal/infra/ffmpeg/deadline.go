@@ -0,0 +1,97 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+var errJobDeadline = errors.New("job deadline exceeded")
+
+// jobDeadline cancels a job after a base timeout that can be extended once the
+// video duration is known.
+type jobDeadline struct {
+	ctx       context.Context
+	cancel    context.CancelCauseFunc
+	base      time.Duration
+	factor    float64
+	startedAt time.Time
+
+	mu    sync.Mutex
+	timer *time.Timer
+	limit time.Duration
+}
+
+func newJobDeadline(parent context.Context, base time.Duration, factor float64) *jobDeadline {
+	ctx, cancel := context.WithCancelCause(parent)
+
+	d := &jobDeadline{
+		ctx:       ctx,
+		cancel:    cancel,
+		base:      base,
+		factor:    factor,
+		startedAt: time.Now(),
+	}
+
+	if base > 0 {
+		d.arm(base)
+	}
+
+	return d
+}
+
+func (d *jobDeadline) Context() context.Context {
+	return d.ctx
+}
+
+func (d *jobDeadline) ScaleTo(videoDuration time.Duration) {
+	if d.factor <= 0 || videoDuration <= 0 {
+		return
+	}
+	d.arm(d.base + time.Duration(float64(videoDuration)*d.factor))
+}
+
+func (d *jobDeadline) arm(limit time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.ctx.Err() != nil {
+		return
+	}
+
+	d.limit = limit
+	remaining := limit - time.Since(d.startedAt)
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timer = time.AfterFunc(remaining, func() {
+		d.cancel(errJobDeadline)
+	})
+}
+
+func (d *jobDeadline) Stop() {
+	d.mu.Lock()
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.mu.Unlock()
+
+	d.cancel(nil)
+}
+
+// Wrap reports errors caused by the deadline as processing timeouts.
+func (d *jobDeadline) Wrap(err error) error {
+	if err == nil || !errors.Is(context.Cause(d.ctx), errJobDeadline) {
+		return err
+	}
+
+	d.mu.Lock()
+	limit := d.limit
+	d.mu.Unlock()
+
+	return fmt.Errorf("%w after %s: %v", customerrors.ErrProcessingTimeout, limit, err)
+}
@@ -0,0 +1,214 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"upframer-worker/internal/domain/entities"
+)
+
+// extractFrames runs ffmpeg for the requested mode over window and returns
+// the written frames in output order with the source timestamp each one was
+// taken at. duration is the length of the window in seconds. Scene and
+// keyframe modes fall back to uniform sampling when too few frames are
+// selected and drop evenly spaced frames when too many are.
+func extractFrames(ctx context.Context, videoPath, outputDir string, opts entities.ExtractionOptions, window timeWindow, duration float64, onProgress func(progressUpdate)) ([]entities.Frame, error) {
+	frames, err := runExtraction(ctx, videoPath, outputDir, opts, window, onProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Mode == entities.ModeInterval {
+		return frames, nil
+	}
+
+	if opts.MinFrames > 0 && len(frames) < opts.MinFrames && duration > 0 {
+		log.Printf("Mode %s selected %d frames, below the minimum of %d; sampling uniformly instead", opts.Mode, len(frames), opts.MinFrames)
+
+		if err := clearDir(outputDir); err != nil {
+			return nil, err
+		}
+
+		fallback := opts
+		fallback.Mode = entities.ModeInterval
+		fallback.FPS = min(float64(opts.MinFrames)/duration, entities.MaxFPS)
+
+		frames, err = runExtraction(ctx, videoPath, outputDir, fallback, window, onProgress)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.MaxFrames > 0 && len(frames) > opts.MaxFrames {
+		return thinFrames(outputDir, frames, opts.MaxFrames)
+	}
+
+	return frames, nil
+}
+
+func runExtraction(ctx context.Context, videoPath, outputDir string, opts entities.ExtractionOptions, window timeWindow, onProgress func(progressUpdate)) ([]entities.Frame, error) {
+	showinfo := &showinfoParser{offset: window.start}
+
+	if err := runFFmpeg(ctx, buildExtractionArgs(videoPath, outputDir, opts, window), onProgress, showinfo.parse); err != nil {
+		return nil, err
+	}
+
+	return listFrames(outputDir, showinfo.frames)
+}
+
+// extractAtTimestamps seeks to each timestamp in turn and writes one frame,
+// so only the GOPs around the requested points are decoded. Progress follows
+// the position in the video, as timestamps are visited in ascending order.
+func extractAtTimestamps(ctx context.Context, videoPath, outputDir string, opts entities.ExtractionOptions, timestamps []float64, onProgress func(progressUpdate)) ([]entities.Frame, error) {
+	sorted := append([]float64(nil), timestamps...)
+	sort.Float64s(sorted)
+
+	var frames []entities.Frame
+	for i, timestamp := range sorted {
+		name := fmt.Sprintf("frame_%04d.%s", len(frames)+1, opts.Format)
+		outputPath := filepath.Join(outputDir, name)
+
+		showinfo := &showinfoParser{}
+		if err := runFFmpeg(ctx, buildSeekArgs(videoPath, outputPath, timestamp, opts), nil, showinfo.parse); err != nil {
+			return nil, err
+		}
+
+		if _, err := os.Stat(outputPath); err != nil {
+			log.Printf("No frame decoded at %gs of %s, skipping", timestamp, videoPath)
+		} else {
+			frame := entities.Frame{
+				Index:     len(frames) + 1,
+				File:      name,
+				Timestamp: timestamp,
+			}
+			if len(showinfo.frames) > 0 {
+				frame.Width = showinfo.frames[0].width
+				frame.Height = showinfo.frames[0].height
+			}
+			frames = append(frames, frame)
+		}
+
+		onProgress(progressUpdate{
+			outTime: time.Duration(timestamp * float64(time.Second)),
+			frames:  len(frames),
+			done:    i == len(sorted)-1,
+		})
+	}
+
+	return frames, nil
+}
+
+// listFrames pairs the frame files, which ffmpeg numbers in output order,
+// with the timestamps and sizes showinfo reported for them. Files are ordered
+// by their number, as frame_10000 sorts before frame_1001 by name.
+func listFrames(dir string, infos []frameInfo) ([]entities.Frame, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading frames directory: %v", err)
+	}
+
+	var names []string
+	numbers := make(map[string]int)
+	for _, entry := range entries {
+		number, ok := frameNumber(entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+		names = append(names, entry.Name())
+		numbers[entry.Name()] = number
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return numbers[names[i]] < numbers[names[j]]
+	})
+
+	var frames []entities.Frame
+	for _, name := range names {
+		frame := entities.Frame{
+			Index: len(frames) + 1,
+			File:  name,
+		}
+		if len(frames) < len(infos) {
+			info := infos[len(frames)]
+			frame.Timestamp = info.timestamp
+			frame.Width = info.width
+			frame.Height = info.height
+		}
+		frames = append(frames, frame)
+	}
+
+	if len(infos) != len(frames) {
+		log.Printf("ffmpeg reported %d frame timestamps for %d frames in %s", len(infos), len(frames), dir)
+	}
+
+	return frames, nil
+}
+
+// frameNumber parses the output number out of a frame_%04d file name.
+func frameNumber(name string) (int, bool) {
+	if !strings.HasPrefix(name, "frame_") {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "frame_"), filepath.Ext(name)))
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// thinFrames keeps limit evenly spaced frames, deletes the rest and renumbers
+// the survivors so the file names stay contiguous.
+func thinFrames(dir string, frames []entities.Frame, limit int) ([]entities.Frame, error) {
+	keep := make(map[int]bool, limit)
+	for i := 0; i < limit; i++ {
+		if limit == 1 {
+			keep[0] = true
+			break
+		}
+		keep[i*(len(frames)-1)/(limit-1)] = true
+	}
+
+	kept := make([]entities.Frame, 0, limit)
+	for i, frame := range frames {
+		if keep[i] {
+			kept = append(kept, frame)
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, frame.File)); err != nil {
+			return nil, fmt.Errorf("error removing frame %s: %v", frame.File, err)
+		}
+	}
+
+	for i := range kept {
+		name := fmt.Sprintf("frame_%04d%s", i+1, filepath.Ext(kept[i].File))
+		if name != kept[i].File {
+			if err := os.Rename(filepath.Join(dir, kept[i].File), filepath.Join(dir, name)); err != nil {
+				return nil, fmt.Errorf("error renaming frame %s: %v", kept[i].File, err)
+			}
+		}
+		kept[i].Index = i + 1
+		kept[i].File = name
+	}
+
+	return kept, nil
+}
+
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading frames directory: %v", err)
+	}
+
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("error clearing frames directory: %v", err)
+		}
+	}
+	return nil
+}
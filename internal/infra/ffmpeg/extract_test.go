@@ -0,0 +1,103 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"upframer-worker/internal/domain/entities"
+)
+
+func writeFrames(t *testing.T, dir string, count int) []entities.Frame {
+	t.Helper()
+
+	frames := make([]entities.Frame, count)
+	for i := range frames {
+		name := fmt.Sprintf("frame_%04d.jpg", i+1)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		frames[i] = entities.Frame{Index: i + 1, File: name, Timestamp: float64(i)}
+	}
+	return frames
+}
+
+func TestThinFrames(t *testing.T) {
+	tests := []struct {
+		count      int
+		limit      int
+		timestamps []float64
+	}{
+		{count: 10, limit: 4, timestamps: []float64{0, 3, 6, 9}},
+		{count: 5, limit: 1, timestamps: []float64{0}},
+		{count: 3, limit: 3, timestamps: []float64{0, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		frames := writeFrames(t, dir, tt.count)
+
+		kept, err := thinFrames(dir, frames, tt.limit)
+		if err != nil {
+			t.Fatalf("Failed to thin %d frames to %d: %v", tt.count, tt.limit, err)
+		}
+
+		if len(kept) != len(tt.timestamps) {
+			t.Fatalf("Expected %d frames, got %d", len(tt.timestamps), len(kept))
+		}
+
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != tt.limit {
+			t.Errorf("Expected %d files left, got %d", tt.limit, len(entries))
+		}
+
+		for i, frame := range kept {
+			name := fmt.Sprintf("frame_%04d.jpg", i+1)
+			if frame.Index != i+1 || frame.File != name || frame.Timestamp != tt.timestamps[i] {
+				t.Errorf("Frame %d: expected #%d %s at %gs, got %+v", i, i+1, name, tt.timestamps[i], frame)
+			}
+
+			// renamed files keep the content of the frame they were taken from
+			content, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Errorf("Expected %s to exist: %v", name, err)
+				continue
+			}
+			if original := fmt.Sprintf("frame_%04d.jpg", int(tt.timestamps[i])+1); string(content) != original {
+				t.Errorf("Expected %s to hold %s, got %s", name, original, content)
+			}
+		}
+	}
+}
+
+func TestListFrames_NumericOrder(t *testing.T) {
+	const count = 10050
+
+	dir := t.TempDir()
+	infos := make([]frameInfo, count)
+	for i := range infos {
+		name := fmt.Sprintf("frame_%04d.jpg", i+1)
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		infos[i] = frameInfo{timestamp: float64(i), width: 1280, height: 720}
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifestJSONName), nil, 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	frames, err := listFrames(dir, infos)
+	if err != nil {
+		t.Fatalf("Failed to list frames: %v", err)
+	}
+
+	if len(frames) != count {
+		t.Fatalf("Expected %d frames, got %d", count, len(frames))
+	}
+	for i, frame := range frames {
+		name := fmt.Sprintf("frame_%04d.jpg", i+1)
+		if frame.Index != i+1 || frame.File != name || frame.Timestamp != float64(i) {
+			t.Fatalf("Frame %d: expected #%d %s at %gs, got %+v", i, i+1, name, float64(i), frame)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package ffmpeg
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"upframer-worker/internal/domain/entities"
+	"upframer-worker/internal/infra/util"
+)
+
+const (
+	manifestJSONName = "manifest.json"
+	manifestCSVName  = "manifest.csv"
+)
+
+type manifest struct {
+	JobId       string                     `json:"jobId"`
+	VideoName   string                     `json:"videoName,omitempty"`
+	Source      manifestSource             `json:"source"`
+	Options     entities.ExtractionOptions `json:"options"`
+	StartTime   float64                    `json:"startTime,omitempty"`
+	EndTime     float64                    `json:"endTime,omitempty"`
+	Timestamps  []float64                  `json:"timestamps,omitempty"`
+	GeneratedAt time.Time                  `json:"generatedAt"`
+	FrameCount  int                        `json:"frameCount"`
+	Frames      []manifestFrame            `json:"frames"`
+}
+
+type manifestSource struct {
+	Path     string  `json:"path"`
+	Duration float64 `json:"duration"`
+	Width    int     `json:"width"`
+	Height   int     `json:"height"`
+	Codec    string  `json:"codec"`
+	Size     int64   `json:"size"`
+}
+
+type manifestFrame struct {
+	Index     int     `json:"index"`
+	File      string  `json:"file"`
+	Timestamp float64 `json:"timestamp"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Size      int64   `json:"size"`
+	SHA256    string  `json:"sha256"`
+}
+
+// writeManifest fills in the size and checksum of every frame and writes
+// manifest.json, plus manifest.csv when requested, next to the frames so
+// they end up in the archive.
+func writeManifest(dir string, job *entities.VideoJob, opts entities.ExtractionOptions, metadata *entities.VideoMetadata, frames []entities.Frame) ([]entities.Frame, error) {
+	m := manifest{
+		JobId:     job.JobId,
+		VideoName: job.VideoName,
+		Source: manifestSource{
+			Path:     sourcePath(job.VideoPath),
+			Duration: metadata.Duration,
+			Width:    metadata.Width,
+			Height:   metadata.Height,
+			Codec:    metadata.Codec,
+			Size:     metadata.Size,
+		},
+		Options:     opts,
+		StartTime:   job.StartTime,
+		EndTime:     job.EndTime,
+		Timestamps:  job.Timestamps,
+		GeneratedAt: time.Now().UTC(),
+		FrameCount:  len(frames),
+		Frames:      make([]manifestFrame, 0, len(frames)),
+	}
+
+	for i := range frames {
+		info, err := util.ChecksumFile(filepath.Join(dir, frames[i].File))
+		if err != nil {
+			return nil, fmt.Errorf("error hashing frame %s: %v", frames[i].File, err)
+		}
+		frames[i].Size = info.Size
+		frames[i].Checksum = info.Checksum
+
+		m.Frames = append(m.Frames, manifestFrame{
+			Index:     frames[i].Index,
+			File:      frames[i].File,
+			Timestamp: frames[i].Timestamp,
+			Width:     frames[i].Width,
+			Height:    frames[i].Height,
+			Size:      info.Size,
+			SHA256:    info.Checksum,
+		})
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding manifest: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, manifestJSONName), data, 0644); err != nil {
+		return nil, fmt.Errorf("error writing manifest: %v", err)
+	}
+
+	if opts.ManifestCSV {
+		if err := writeManifestCSV(filepath.Join(dir, manifestCSVName), m.Frames); err != nil {
+			return nil, err
+		}
+	}
+
+	return frames, nil
+}
+
+func writeManifestCSV(path string, frames []manifestFrame) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating CSV manifest: %v", err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"index", "file", "timestamp", "width", "height", "size", "sha256"})
+
+	for _, frame := range frames {
+		w.Write([]string{
+			strconv.Itoa(frame.Index),
+			frame.File,
+			strconv.FormatFloat(frame.Timestamp, 'f', -1, 64),
+			strconv.Itoa(frame.Width),
+			strconv.Itoa(frame.Height),
+			strconv.FormatInt(frame.Size, 10),
+			frame.SHA256,
+		})
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("error writing CSV manifest: %v", err)
+	}
+
+	return file.Close()
+}
+
+// sourcePath drops the query string, which for presigned URLs carries
+// credentials that must not end up in the archive.
+func sourcePath(videoPath string) string {
+	if i := strings.IndexByte(videoPath, '?'); i >= 0 {
+		return videoPath[:i]
+	}
+	return videoPath
+}
@@ -0,0 +1,139 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"upframer-worker/internal/domain/entities"
+)
+
+// timeWindow is the part of the video to decode, in seconds. A zero end
+// means the end of the video.
+type timeWindow struct {
+	start float64
+	end   float64
+}
+
+func (w timeWindow) length(duration float64) float64 {
+	if w.end > 0 {
+		return w.end - w.start
+	}
+	return max(duration-w.start, 0)
+}
+
+func buildExtractionArgs(videoPath, outputDir string, opts entities.ExtractionOptions, window timeWindow) []string {
+	var args []string
+
+	// Input seeking: ffmpeg jumps straight to start and stops reading at end
+	// instead of decoding the whole file.
+	if window.start > 0 {
+		args = append(args, "-ss", formatSeconds(window.start))
+	}
+	if window.end > 0 {
+		args = append(args, "-to", formatSeconds(window.end))
+	}
+
+	if opts.Mode == entities.ModeKeyframes {
+		// Decoder option, so it has to precede the input: everything but
+		// I-frames is skipped without being decoded.
+		args = append(args, "-skip_frame", "nokey")
+	}
+
+	args = append(args,
+		"-i", videoPath,
+		"-vf", buildFilterGraph(opts),
+	)
+
+	if opts.Mode == entities.ModeScene || opts.Mode == entities.ModeKeyframes {
+		// Only write the frames select lets through instead of duplicating
+		// them to a constant output rate.
+		args = append(args, "-vsync", "vfr")
+	}
+
+	args = append(args, qualityArgs(opts)...)
+
+	return append(args,
+		"-y",
+		filepath.Join(outputDir, "frame_%04d."+opts.Format),
+	)
+}
+
+// buildSeekArgs writes the single frame at timestamp to outputPath.
+func buildSeekArgs(videoPath, outputPath string, timestamp float64, opts entities.ExtractionOptions) []string {
+	args := []string{
+		"-ss", formatSeconds(timestamp),
+		"-i", videoPath,
+		"-frames:v", "1",
+	}
+
+	filters := []string{"showinfo"}
+	if scale := scaleFilter(opts.Width, opts.Height); scale != "" {
+		filters = []string{scale, "showinfo"}
+	}
+	args = append(args, "-vf", strings.Join(filters, ","))
+
+	args = append(args, qualityArgs(opts)...)
+
+	return append(args,
+		"-update", "1",
+		"-y",
+		outputPath,
+	)
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
+}
+
+// buildFilterGraph ends with showinfo so every written frame logs its
+// pts_time on stderr, in output order.
+func buildFilterGraph(opts entities.ExtractionOptions) string {
+	var filters []string
+
+	switch opts.Mode {
+	case entities.ModeScene:
+		filters = append(filters, fmt.Sprintf("select='gt(scene,%s)'", strconv.FormatFloat(opts.SceneThreshold, 'f', -1, 64)))
+	case entities.ModeKeyframes:
+	default:
+		filters = append(filters, "fps="+strconv.FormatFloat(opts.FPS, 'f', -1, 64))
+	}
+
+	if scale := scaleFilter(opts.Width, opts.Height); scale != "" {
+		filters = append(filters, scale)
+	}
+
+	filters = append(filters, "showinfo")
+
+	return strings.Join(filters, ",")
+}
+
+func scaleFilter(width, height int) string {
+	switch {
+	case width > 0 && height > 0:
+		return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", width, height)
+	case width > 0:
+		return fmt.Sprintf("scale=%d:-2", width)
+	case height > 0:
+		return fmt.Sprintf("scale=-2:%d", height)
+	default:
+		return ""
+	}
+}
+
+func qualityArgs(opts entities.ExtractionOptions) []string {
+	if opts.Quality == 0 {
+		return nil
+	}
+
+	switch opts.Format {
+	case entities.FormatJPG:
+		// mjpeg qscale runs from 2 (best) to 31 (worst)
+		qscale := 2 + (100-opts.Quality)*29/99
+		return []string{"-q:v", strconv.Itoa(qscale)}
+	case entities.FormatWebP:
+		return []string{"-quality", strconv.Itoa(opts.Quality)}
+	default:
+		return nil
+	}
+}
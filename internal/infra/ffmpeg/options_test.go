@@ -0,0 +1,115 @@
+package ffmpeg
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"upframer-worker/internal/domain/entities"
+)
+
+func TestBuildExtractionArgs(t *testing.T) {
+	output := filepath.Join("out", "frame_%04d.jpg")
+
+	tests := []struct {
+		name     string
+		opts     entities.ExtractionOptions
+		window   timeWindow
+		expected []string
+	}{
+		{
+			name:     "whole video",
+			opts:     entities.ExtractionOptions{Mode: entities.ModeInterval, FPS: 1, Format: entities.FormatJPG},
+			expected: []string{"-i", "video.mp4", "-vf", "fps=1,showinfo", "-y", output},
+		},
+		{
+			name:     "window seeks before the input",
+			opts:     entities.ExtractionOptions{Mode: entities.ModeInterval, FPS: 0.5, Format: entities.FormatJPG},
+			window:   timeWindow{start: 12.5, end: 30},
+			expected: []string{"-ss", "12.5", "-to", "30", "-i", "video.mp4", "-vf", "fps=0.5,showinfo", "-y", output},
+		},
+		{
+			name:     "start only",
+			opts:     entities.ExtractionOptions{Mode: entities.ModeInterval, FPS: 1, Format: entities.FormatJPG},
+			window:   timeWindow{start: 5},
+			expected: []string{"-ss", "5", "-i", "video.mp4", "-vf", "fps=1,showinfo", "-y", output},
+		},
+		{
+			name:     "end only",
+			opts:     entities.ExtractionOptions{Mode: entities.ModeInterval, FPS: 1, Format: entities.FormatJPG},
+			window:   timeWindow{end: 8},
+			expected: []string{"-to", "8", "-i", "video.mp4", "-vf", "fps=1,showinfo", "-y", output},
+		},
+		{
+			name:     "keyframes skip decoding before the input",
+			opts:     entities.ExtractionOptions{Mode: entities.ModeKeyframes, Format: entities.FormatJPG},
+			window:   timeWindow{start: 1, end: 2},
+			expected: []string{"-ss", "1", "-to", "2", "-skip_frame", "nokey", "-i", "video.mp4", "-vf", "showinfo", "-vsync", "vfr", "-y", output},
+		},
+		{
+			name:     "scene with scale and quality",
+			opts:     entities.ExtractionOptions{Mode: entities.ModeScene, SceneThreshold: 0.3, Format: entities.FormatJPG, Width: 320, Quality: 100},
+			expected: []string{"-i", "video.mp4", "-vf", "select='gt(scene,0.3)',scale=320:-2,showinfo", "-vsync", "vfr", "-q:v", "2", "-y", output},
+		},
+		{
+			name:     "webp quality and box scale",
+			opts:     entities.ExtractionOptions{Mode: entities.ModeInterval, FPS: 2, Format: entities.FormatWebP, Width: 320, Height: 240, Quality: 80},
+			expected: []string{"-i", "video.mp4", "-vf", "fps=2,scale=320:240:force_original_aspect_ratio=decrease,showinfo", "-quality", "80", "-y", filepath.Join("out", "frame_%04d.webp")},
+		},
+	}
+
+	for _, tt := range tests {
+		args := buildExtractionArgs("video.mp4", "out", tt.opts, tt.window)
+
+		if !slices.Equal(args, tt.expected) {
+			t.Errorf("%s: expected %q, got %q", tt.name, strings.Join(tt.expected, " "), strings.Join(args, " "))
+		}
+	}
+}
+
+func TestBuildSeekArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     entities.ExtractionOptions
+		expected []string
+	}{
+		{
+			name:     "no scale",
+			opts:     entities.ExtractionOptions{Format: entities.FormatPNG},
+			expected: []string{"-ss", "61.25", "-i", "video.mp4", "-frames:v", "1", "-vf", "showinfo", "-update", "1", "-y", "frame.png"},
+		},
+		{
+			name:     "scale and quality",
+			opts:     entities.ExtractionOptions{Format: entities.FormatJPG, Height: 240, Quality: 1},
+			expected: []string{"-ss", "61.25", "-i", "video.mp4", "-frames:v", "1", "-vf", "scale=-2:240,showinfo", "-q:v", "31", "-update", "1", "-y", "frame.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		args := buildSeekArgs("video.mp4", "frame.png", 61.25, tt.opts)
+
+		if !slices.Equal(args, tt.expected) {
+			t.Errorf("%s: expected %q, got %q", tt.name, strings.Join(tt.expected, " "), strings.Join(args, " "))
+		}
+	}
+}
+
+func TestTimeWindow_Length(t *testing.T) {
+	tests := []struct {
+		window   timeWindow
+		duration float64
+		expected float64
+	}{
+		{timeWindow{}, 60, 60},
+		{timeWindow{start: 10}, 60, 50},
+		{timeWindow{start: 10, end: 25}, 60, 15},
+		{timeWindow{end: 25}, 0, 25},
+		{timeWindow{start: 10}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if result := tt.window.length(tt.duration); result != tt.expected {
+			t.Errorf("Expected %+v over %gs to last %gs, got %gs", tt.window, tt.duration, tt.expected, result)
+		}
+	}
+}
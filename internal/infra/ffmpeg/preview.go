@@ -0,0 +1,82 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"upframer-worker/internal/domain/entities"
+	customerrors "upframer-worker/internal/domain/errors"
+	"upframer-worker/internal/domain/ports"
+	"upframer-worker/internal/infra/workspace"
+)
+
+var previewContentTypes = map[string]string{
+	entities.PreviewFormatGIF:  "image/gif",
+	entities.PreviewFormatWebP: "image/webp",
+}
+
+// buildPreviewArgs cuts the preview window with input seeking. GIFs get a
+// palette generated from the clip itself, which looks far better than the
+// default 256-color palette.
+func buildPreviewArgs(videoPath, outputPath string, opts entities.PreviewOptions) []string {
+	var args []string
+	if opts.Start > 0 {
+		args = append(args, "-ss", formatSeconds(opts.Start))
+	}
+
+	args = append(args,
+		"-t", formatSeconds(opts.Duration),
+		"-i", videoPath,
+	)
+
+	filters := fmt.Sprintf("fps=%s,scale=%d:-2:flags=lanczos", strconv.FormatFloat(opts.FPS, 'f', -1, 64), opts.Width)
+
+	switch opts.Format {
+	case entities.PreviewFormatWebP:
+		args = append(args,
+			"-vf", filters,
+			"-c:v", "libwebp",
+			"-quality", "75",
+		)
+	default:
+		args = append(args,
+			"-filter_complex", filters+",split[a][b];[a]palettegen[p];[b][p]paletteuse",
+		)
+	}
+
+	return append(args,
+		"-an",
+		"-loop", "0",
+		"-y",
+		outputPath,
+	)
+}
+
+func (p *FFmpegProcessor) createPreview(ctx context.Context, job *entities.VideoJob, videoPath string, ws *workspace.Workspace, duration float64) (*entities.PreviewMetadata, *ports.StorageResult, error) {
+	opts := job.ResolvedPreview()
+
+	if duration > 0 && opts.Start >= duration {
+		return nil, nil, fmt.Errorf("%w: preview start %gs is past the end of the video (%gs)", customerrors.ErrInvalidJobData, opts.Start, duration)
+	}
+
+	fileName := fmt.Sprintf("preview_%s.%s", job.JobId, opts.Format)
+	outputPath := ws.Path(fileName)
+
+	if err := runFFmpeg(ctx, buildPreviewArgs(videoPath, outputPath, opts), nil, nil); err != nil {
+		return nil, nil, err
+	}
+
+	stored, err := p.storage.StoreFile(ctx, outputPath, fileName, previewContentTypes[opts.Format])
+	if err != nil {
+		log.Printf("Error storing preview: %v", err)
+		return nil, nil, err
+	}
+
+	return &entities.PreviewMetadata{
+		URL:      stored.URL,
+		Format:   opts.Format,
+		Size:     stored.Size,
+		Checksum: stored.Checksum,
+	}, stored, nil
+}
@@ -0,0 +1,146 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"upframer-worker/internal/domain/entities"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+type ProbeLimits struct {
+	MaxDuration     time.Duration
+	MaxWidth        int
+	MaxHeight       int
+	MaxSize         int64
+	SupportedCodecs []string
+}
+
+type probeOutput struct {
+	Streams []probeStream `json:"streams"`
+	Format  probeFormat   `json:"format"`
+}
+
+type probeStream struct {
+	CodecType string `json:"codec_type"`
+	CodecName string `json:"codec_name"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Duration  string `json:"duration"`
+
+	Disposition struct {
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+}
+
+type probeFormat struct {
+	Duration string `json:"duration"`
+	Size     string `json:"size"`
+}
+
+func probeVideo(ctx context.Context, videoPath string) (*entities.VideoMetadata, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		videoPath,
+	)
+
+	// only stderr matching a known bad-input pattern makes the failure
+	// permanent; a killed ffprobe or a failed read of a remote input is
+	// retried
+	stderr := &stderrCollector{}
+	cmd.Stderr = stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			stderr.flush()
+			return nil, fmt.Errorf("ffprobe could not read the file: %w", classifyFFmpegError(err, stderr))
+		}
+		return nil, fmt.Errorf("%w: error running ffprobe: %v", customerrors.ErrFFmpegProcessing, err)
+	}
+
+	var probe probeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("%w: error parsing ffprobe output: %v", customerrors.ErrFFmpegProcessing, err)
+	}
+
+	stream := probe.videoStream()
+	if stream == nil {
+		return nil, fmt.Errorf("%w: no video stream found", customerrors.ErrUnsupportedVideo)
+	}
+
+	metadata := &entities.VideoMetadata{
+		Codec:  stream.CodecName,
+		Width:  stream.Width,
+		Height: stream.Height,
+	}
+	metadata.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	metadata.Size, _ = strconv.ParseInt(probe.Format.Size, 10, 64)
+
+	if metadata.Duration == 0 {
+		metadata.Duration, _ = strconv.ParseFloat(stream.Duration, 64)
+	}
+
+	return metadata, nil
+}
+
+func (l ProbeLimits) Validate(metadata *entities.VideoMetadata) error {
+	if metadata.Duration <= 0 {
+		return fmt.Errorf("%w: video has zero duration", customerrors.ErrUnsupportedVideo)
+	}
+
+	if len(l.SupportedCodecs) > 0 && !containsCodec(l.SupportedCodecs, metadata.Codec) {
+		return fmt.Errorf("%w: codec %q is not supported", customerrors.ErrUnsupportedVideo, metadata.Codec)
+	}
+
+	duration := time.Duration(metadata.Duration * float64(time.Second))
+	if l.MaxDuration > 0 && duration > l.MaxDuration {
+		return fmt.Errorf("%w: duration %s exceeds the limit of %s", customerrors.ErrUnsupportedVideo, duration.Round(time.Second), l.MaxDuration)
+	}
+
+	if l.MaxWidth > 0 && metadata.Width > l.MaxWidth {
+		return fmt.Errorf("%w: width %d exceeds the limit of %d", customerrors.ErrUnsupportedVideo, metadata.Width, l.MaxWidth)
+	}
+
+	if l.MaxHeight > 0 && metadata.Height > l.MaxHeight {
+		return fmt.Errorf("%w: height %d exceeds the limit of %d", customerrors.ErrUnsupportedVideo, metadata.Height, l.MaxHeight)
+	}
+
+	if l.MaxSize > 0 && metadata.Size > l.MaxSize {
+		return fmt.Errorf("%w: size %d bytes exceeds the limit of %d bytes", customerrors.ErrUnsupportedVideo, metadata.Size, l.MaxSize)
+	}
+
+	return nil
+}
+
+func (p *probeOutput) videoStream() *probeStream {
+	for i := range p.Streams {
+		stream := &p.Streams[i]
+		if stream.CodecType == "video" && stream.CodecName != "" && stream.Disposition.AttachedPic == 0 {
+			return stream
+		}
+	}
+	return nil
+}
+
+func containsCodec(codecs []string, codec string) bool {
+	for _, supported := range codecs {
+		if strings.EqualFold(strings.TrimSpace(supported), codec) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,116 +1,351 @@
 package ffmpeg
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
+	"path"
+	"path/filepath"
 	"strings"
+	"time"
 	"upframer-worker/internal/domain/entities"
 	customerrors "upframer-worker/internal/domain/errors"
 	"upframer-worker/internal/domain/ports"
+	"upframer-worker/internal/domain/services"
+	"upframer-worker/internal/infra/workspace"
 )
 
+// Frames and the archive are budgeted as a multiple of the source video size,
+// on top of the source itself when it is downloaded.
+const diskReservationFactor = 3
+
+type Config struct {
+	WorkerId         string
+	ProgressInterval time.Duration
+	Limits           ProbeLimits
+	JobTimeout       time.Duration
+	// TimeoutPerVideoSecond extends JobTimeout by this many seconds for each
+	// second of probed video duration.
+	TimeoutPerVideoSecond float64
+	// ArchiveFormat is used for jobs that don't set their own.
+	ArchiveFormat string
+	// UnknownSourceSize is the source size assumed when budgeting disk for a
+	// video whose size neither storage nor ffprobe reports.
+	UnknownSourceSize int64
+	// StorageEndpoint is the custom S3 endpoint, if any, so video URLs on it
+	// are downloaded from storage.
+	StorageEndpoint string
+}
+
 type FFmpegProcessor struct {
-	storage ports.Storage
+	storage    ports.Storage
+	workspaces *workspace.Manager
+	publisher  services.Publisher
+	config     Config
 }
 
-func NewFFmpegProcessor(storage ports.Storage) *FFmpegProcessor {
+func NewFFmpegProcessor(storage ports.Storage, workspaces *workspace.Manager, publisher services.Publisher, config Config) *FFmpegProcessor {
 	return &FFmpegProcessor{
-		storage: storage,
+		storage:    storage,
+		workspaces: workspaces,
+		publisher:  publisher,
+		config:     config,
 	}
 }
 
-func (p *FFmpegProcessor) ProcessVideo(job *entities.VideoJob) (*entities.ProcessingResult, error) {
-	var videoPath string
-	var shouldCleanup bool
+func (p *FFmpegProcessor) ProcessVideo(ctx context.Context, job *entities.VideoJob) (*entities.ProcessingResult, error) {
+	startedAt := time.Now().UTC()
+
+	deadline := newJobDeadline(ctx, p.config.JobTimeout, p.config.TimeoutPerVideoSecond)
+	defer deadline.Stop()
+	ctx = deadline.Context()
+
+	var metadata *entities.VideoMetadata
+
+	failed := func(err error) (*entities.ProcessingResult, error) {
+		err = deadline.Wrap(err)
+		finishedAt := time.Now().UTC()
+		return &entities.ProcessingResult{
+			Status:     entities.StatusFailed,
+			JobId:      job.JobId,
+			Video:      metadata,
+			StartedAt:  &startedAt,
+			FinishedAt: &finishedAt,
+			WorkerId:   p.config.WorkerId,
+		}, err
+	}
+
+	ws, err := p.workspaces.Create(job.JobId)
+	if err != nil {
+		return failed(fmt.Errorf("%w: %v", customerrors.ErrStorageUnavailable, err))
+	}
+	defer ws.Cleanup()
 
-	if strings.HasPrefix(job.VideoPath, "https://") && strings.Contains(job.VideoPath, ".s3.") {
-		localVideoPath := fmt.Sprintf("temp_video_%s.mp4", job.JobId)
+	var videoPath string
+	var reserveAfterProbe bool
 
-		parts := strings.Split(job.VideoPath, "/")
-		if len(parts) >= 4 {
-			s3Key := strings.Join(parts[3:], "/")
+	if s3Key, ok := objectKey(job.VideoPath, p.config.StorageEndpoint); ok {
+		localVideoPath := ws.Path("source" + videoExtension(job.VideoPath))
 
-			err := p.storage.Download(s3Key, localVideoPath)
+		if s3Key != "" {
+			size, err := p.storage.ObjectSize(ctx, s3Key)
 			if err != nil {
-				if strings.Contains(err.Error(), "NoSuchKey") || strings.Contains(err.Error(), "not found") {
-					return &entities.ProcessingResult{
-						Status: "failed",
-						JobId:  job.JobId,
-					}, fmt.Errorf("%w: %v", customerrors.ErrFileNotFound, err)
-				}
-				return &entities.ProcessingResult{
-					Status: "failed",
-					JobId:  job.JobId,
-				}, fmt.Errorf("%w: %v", customerrors.ErrStorageUnavailable, err)
+				return failed(downloadError(err))
+			}
+
+			if limit := p.config.Limits.MaxSize; limit > 0 && size > limit {
+				return failed(fmt.Errorf("%w: size %d bytes exceeds the limit of %d bytes", customerrors.ErrUnsupportedVideo, size, limit))
+			}
+
+			// reserved before downloading, so concurrent downloads can't
+			// fill the disk between them
+			if err := ws.Reserve(ctx, size*(diskReservationFactor+1)); err != nil {
+				return failed(err)
+			}
+
+			if err := p.storage.Download(ctx, s3Key, localVideoPath); err != nil {
+				return failed(downloadError(err))
 			}
 			videoPath = localVideoPath
-			shouldCleanup = true
 		} else {
-			return &entities.ProcessingResult{
-				Status: "failed",
-				JobId:  job.JobId,
-			}, fmt.Errorf("%w: %s", customerrors.ErrInvalidURLFormat, job.VideoPath)
+			return failed(fmt.Errorf("%w: %s", customerrors.ErrInvalidURLFormat, job.VideoPath))
 		}
 	} else {
 		videoPath = job.VideoPath
-		shouldCleanup = false
+
+		if info, err := os.Stat(videoPath); err == nil {
+			if err := ws.Reserve(ctx, info.Size()*diskReservationFactor); err != nil {
+				return failed(err)
+			}
+		} else {
+			// a URL ffmpeg reads itself; its size is only known once probed
+			reserveAfterProbe = true
+		}
 	}
 
-	if shouldCleanup {
-		defer os.Remove(videoPath)
+	metadata, err = probeVideo(ctx, videoPath)
+	if err != nil {
+		return failed(err)
 	}
 
-	outputDir := "frames"
+	if err := p.config.Limits.Validate(metadata); err != nil {
+		return failed(err)
+	}
 
-	err := os.MkdirAll(outputDir, 0755)
+	if reserveAfterProbe {
+		size := metadata.Size
+		if size <= 0 {
+			size = p.config.UnknownSourceSize
+		}
+		if err := ws.Reserve(ctx, size*diskReservationFactor); err != nil {
+			return failed(err)
+		}
+	}
 
+	window, err := resolveWindow(job, metadata.Duration)
 	if err != nil {
-		log.Fatal("Error creating directory: ", err)
-		return &entities.ProcessingResult{
-			Status: "failed",
-			JobId:  job.JobId,
-		}, err
+		return failed(err)
 	}
 
-	outputName := outputDir + "/frame_%04d.jpg"
+	duration := time.Duration(metadata.Duration * float64(time.Second))
+	deadline.ScaleTo(duration)
+
+	outputDir := ws.FramesDir()
 
-	cmd := exec.Command("ffmpeg",
-		"-i", videoPath,
-		"-vf", "fps=1",
-		"-y",
-		outputName,
-	)
+	opts := job.ResolvedOptions()
+	var frames []entities.Frame
 
-	_, err = cmd.CombinedOutput()
+	switch {
+	case !job.NeedsFrames():
+	case len(job.Timestamps) > 0:
+		reporter := newProgressReporter(p.publisher, job.JobId, p.config.WorkerId, duration, p.config.ProgressInterval)
+		frames, err = extractAtTimestamps(ctx, videoPath, outputDir, opts, job.Timestamps, reporter.Report)
+		reporter.Close()
+	default:
+		windowLength := window.length(metadata.Duration)
+		reporter := newProgressReporter(p.publisher, job.JobId, p.config.WorkerId, time.Duration(windowLength*float64(time.Second)), p.config.ProgressInterval)
+		frames, err = extractFrames(ctx, videoPath, outputDir, opts, window, windowLength, reporter.Report)
+		reporter.Close()
+	}
 
 	if err != nil {
-		return &entities.ProcessingResult{
-			Status: "failed",
-			JobId:  job.JobId,
-		}, err
+		return failed(err)
 	}
 
-	zipFileName := fmt.Sprintf("frames_%s.zip", job.JobId)
+	result := &entities.ProcessingResult{
+		Status:     entities.StatusCompleted,
+		JobId:      job.JobId,
+		FrameCount: len(frames),
+		Video:      metadata,
+		StartedAt:  &startedAt,
+		WorkerId:   p.config.WorkerId,
+	}
 
-	storageResult, err := p.storage.StoreZip(outputDir, zipFileName)
-	if err != nil {
-		log.Fatal("Error storing ZIP: ", err)
-		return &entities.ProcessingResult{
-			Status: "failed",
-			JobId:  job.JobId,
-		}, err
+	// Outputs run from least to most primary, so OutputPath ends up pointing
+	// at the archive when there is one.
+	if job.HasOutput(entities.OutputPreview) {
+		preview, stored, err := p.createPreview(ctx, job, videoPath, ws, metadata.Duration)
+		if err != nil {
+			return failed(err)
+		}
+		result.Preview = preview
+		setOutput(result, stored)
+	}
+
+	if job.HasOutput(entities.OutputSprite) {
+		sheets, err := generateSprites(ctx, outputDir, ws.Path("sprites"), opts.Format, frames, job.ResolvedSprite(), metadata)
+		if err != nil {
+			return failed(err)
+		}
+
+		sprites, stored, err := p.storeSprites(ctx, job.JobId, sheets)
+		if err != nil {
+			log.Printf("Error storing sprites: %v", err)
+			return failed(err)
+		}
+		result.Sprites = sprites
+		setOutput(result, stored)
+	}
+
+	if job.HasOutput(entities.OutputZip) || job.HasOutput(entities.OutputFrames) {
+		frames, err = writeManifest(outputDir, job, opts, metadata, frames)
+		if err != nil {
+			return failed(err)
+		}
+	}
+
+	if job.HasOutput(entities.OutputFrames) {
+		// the manifests go last, so they never list frames not yet uploaded
+		uploaded, err := p.storage.UploadDirectory(ctx, outputDir, fmt.Sprintf("frames_%s/", job.JobId), manifestCSVName, manifestJSONName)
+		if err != nil {
+			log.Printf("Error uploading frames: %v", err)
+			return failed(err)
+		}
+
+		result.FrameObjects = &entities.FrameObjects{
+			Prefix: uploaded.Prefix,
+			Count:  len(frames),
+		}
+		if manifest, ok := uploaded.Files[manifestJSONName]; ok {
+			result.FrameObjects.ManifestURL = manifest.URL
+			setOutput(result, manifest)
+		}
+	}
+
+	if job.HasOutput(entities.OutputZip) {
+		format := p.archiveFormat(job)
+		archiveFileName := fmt.Sprintf("frames_%s.%s", job.JobId, format)
+
+		storageResult, err := p.storage.StoreArchive(ctx, outputDir, archiveFileName, format)
+		if err != nil {
+			log.Printf("Error storing archive: %v", err)
+			return failed(err)
+		}
+
+		setOutput(result, storageResult)
+		result.Archive = &entities.ArchiveMetadata{
+			Size:        storageResult.Size,
+			Checksum:    storageResult.Checksum,
+			Format:      format,
+			ContentType: storageResult.ContentType,
+		}
+	}
+
+	finishedAt := time.Now().UTC()
+	result.Frames = frames
+	result.FinishedAt = &finishedAt
+
+	return result, nil
+}
+
+func downloadError(err error) error {
+	if strings.Contains(err.Error(), "NoSuchKey") || strings.Contains(err.Error(), "not found") {
+		return fmt.Errorf("%w: %v", customerrors.ErrFileNotFound, err)
 	}
+	return fmt.Errorf("%w: %v", customerrors.ErrStorageUnavailable, err)
+}
+
+func setOutput(result *entities.ProcessingResult, stored *ports.StorageResult) {
+	result.OutputPath = stored.URL
+	result.OutputKey = stored.Path
+	result.DownloadURL = stored.PresignedURL
+	result.DownloadURLExpiresAt = stored.PresignedURLExpiresAt
+}
 
-	err = os.RemoveAll(outputDir)
+func (p *FFmpegProcessor) archiveFormat(job *entities.VideoJob) string {
+	switch {
+	case job.ArchiveFormat != "":
+		return job.ArchiveFormat
+	case p.config.ArchiveFormat != "":
+		return p.config.ArchiveFormat
+	default:
+		return entities.ArchiveZip
+	}
+}
+
+// storeSprites uploads the sheets and their track under one prefix, so the
+// relative sheet names in the track resolve.
+func (p *FFmpegProcessor) storeSprites(ctx context.Context, jobId string, sheets *spriteSheets) (*entities.SpriteMetadata, *ports.StorageResult, error) {
+	prefix := fmt.Sprintf("sprites_%s/", jobId)
+
+	metadata := &entities.SpriteMetadata{
+		Columns:    sheets.opts.Columns,
+		Rows:       sheets.opts.Rows,
+		TileWidth:  sheets.opts.TileWidth,
+		TileHeight: sheets.opts.TileHeight,
+	}
+
+	for _, sheet := range sheets.sheets {
+		stored, err := p.storage.StoreFile(ctx, filepath.Join(sheets.dir, sheet), prefix+sheet, "image/jpeg")
+		if err != nil {
+			return nil, nil, err
+		}
+		metadata.SheetURLs = append(metadata.SheetURLs, stored.URL)
+	}
+
+	stored, err := p.storage.StoreFile(ctx, filepath.Join(sheets.dir, sheets.track), prefix+sheets.track, "text/vtt")
 	if err != nil {
-		log.Printf("Warning: Error removing frames directory %s: %v\n", outputDir, err)
+		return nil, nil, err
 	}
+	metadata.TrackURL = stored.URL
 
-	return &entities.ProcessingResult{
-		Status:     "completed",
-		JobId:      job.JobId,
-		OutputPath: storageResult.URL,
-	}, nil
+	return metadata, stored, nil
+}
+
+// resolveWindow checks the requested window and timestamps against the probed
+// duration and clamps an end past the end of the video.
+func resolveWindow(job *entities.VideoJob, duration float64) (timeWindow, error) {
+	window := timeWindow{start: job.StartTime, end: job.EndTime}
+	if duration <= 0 {
+		// unknown duration, let ffmpeg stop at the end of the stream
+		return window, nil
+	}
+
+	for _, timestamp := range job.Timestamps {
+		if timestamp > duration {
+			return timeWindow{}, fmt.Errorf("%w: timestamp %gs is past the end of the video (%gs)", customerrors.ErrInvalidJobData, timestamp, duration)
+		}
+	}
+
+	if job.StartTime > 0 && job.StartTime >= duration {
+		return timeWindow{}, fmt.Errorf("%w: startTime %gs is past the end of the video (%gs)", customerrors.ErrInvalidJobData, job.StartTime, duration)
+	}
+
+	if window.end >= duration {
+		window.end = 0
+	}
+	return window, nil
+}
+
+func videoExtension(videoURL string) string {
+	if i := strings.IndexAny(videoURL, "?#"); i >= 0 {
+		videoURL = videoURL[:i]
+	}
+
+	ext := strings.ToLower(path.Ext(videoURL))
+	if ext == "" || len(ext) > 5 {
+		return ".mp4"
+	}
+	return ext
 }
@@ -0,0 +1,55 @@
+package ffmpeg
+
+import (
+	"errors"
+	"testing"
+	"upframer-worker/internal/domain/entities"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+func TestResolveWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      entities.VideoJob
+		duration float64
+		expected timeWindow
+	}{
+		{"whole video", entities.VideoJob{}, 60, timeWindow{}},
+		{"window inside the video", entities.VideoJob{StartTime: 10, EndTime: 20}, 60, timeWindow{start: 10, end: 20}},
+		{"end clamped at the duration", entities.VideoJob{StartTime: 10, EndTime: 60}, 60, timeWindow{start: 10}},
+		{"end clamped past the duration", entities.VideoJob{StartTime: 10, EndTime: 90}, 60, timeWindow{start: 10}},
+		{"unknown duration passes through", entities.VideoJob{StartTime: 100, EndTime: 200}, 0, timeWindow{start: 100, end: 200}},
+		{"timestamps inside the video", entities.VideoJob{Timestamps: []float64{0, 30, 60}}, 60, timeWindow{}},
+		{"timestamps with unknown duration", entities.VideoJob{Timestamps: []float64{600}}, 0, timeWindow{}},
+	}
+
+	for _, tt := range tests {
+		window, err := resolveWindow(&tt.job, tt.duration)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tt.name, err)
+			continue
+		}
+		if window != tt.expected {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.expected, window)
+		}
+	}
+}
+
+func TestResolveWindow_Rejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		job      entities.VideoJob
+		duration float64
+	}{
+		{"start at the end", entities.VideoJob{StartTime: 60}, 60},
+		{"start past the end", entities.VideoJob{StartTime: 75, EndTime: 90}, 60},
+		{"timestamp past the end", entities.VideoJob{Timestamps: []float64{10, 60.5}}, 60},
+	}
+
+	for _, tt := range tests {
+		_, err := resolveWindow(&tt.job, tt.duration)
+		if !errors.Is(err, customerrors.ErrInvalidJobData) {
+			t.Errorf("%s: expected ErrInvalidJobData, got %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"upframer-worker/internal/domain/entities"
+	"upframer-worker/internal/domain/services"
+)
+
+const ProgressQueueName = "video-processing-progress"
+
+type progressUpdate struct {
+	outTime time.Duration
+	frames  int
+	done    bool
+}
+
+// parseProgress reads the key=value blocks written by "ffmpeg -progress" and
+// calls fn at the end of each block.
+func parseProgress(r io.Reader, fn func(progressUpdate)) {
+	scanner := bufio.NewScanner(r)
+	update := progressUpdate{}
+
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "frame":
+			if frames, err := strconv.Atoi(value); err == nil {
+				update.frames = frames
+			}
+		case "out_time_us", "out_time_ms":
+			// both keys are reported in microseconds
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+				update.outTime = time.Duration(us) * time.Microsecond
+			}
+		case "progress":
+			update.done = value == "end"
+			fn(update)
+		}
+	}
+}
+
+type progressReporter struct {
+	publisher services.Publisher
+	jobId     string
+	workerId  string
+	duration  time.Duration
+	interval  time.Duration
+	startedAt time.Time
+	lastSent  time.Time
+	events    chan *entities.ProgressEvent
+	finished  chan struct{}
+}
+
+func newProgressReporter(publisher services.Publisher, jobId, workerId string, duration, interval time.Duration) *progressReporter {
+	r := &progressReporter{
+		publisher: publisher,
+		jobId:     jobId,
+		workerId:  workerId,
+		duration:  duration,
+		interval:  interval,
+		startedAt: time.Now(),
+		events:    make(chan *entities.ProgressEvent, 1),
+		finished:  make(chan struct{}),
+	}
+
+	go r.run()
+	return r
+}
+
+// run publishes off the ffmpeg read loop so a slow broker never stalls ffmpeg.
+func (r *progressReporter) run() {
+	defer close(r.finished)
+
+	for event := range r.events {
+		if err := r.publisher.PublishProgress(ProgressQueueName, event); err != nil {
+			log.Printf("Warning: error publishing progress for job %s: %v", r.jobId, err)
+		}
+	}
+}
+
+func (r *progressReporter) Report(update progressUpdate) {
+	now := time.Now()
+	if !update.done && now.Sub(r.lastSent) < r.interval {
+		return
+	}
+	r.lastSent = now
+
+	event := &entities.ProgressEvent{
+		JobId:     r.jobId,
+		Frames:    update.frames,
+		Timestamp: now.UTC(),
+		WorkerId:  r.workerId,
+	}
+
+	if r.duration > 0 {
+		fraction := math.Min(float64(update.outTime)/float64(r.duration), 1)
+		if update.done {
+			fraction = 1
+		}
+		event.Percent = math.Round(fraction*1000) / 10
+
+		if fraction > 0 {
+			elapsed := now.Sub(r.startedAt).Seconds()
+			event.ETASeconds = math.Round(elapsed * (1 - fraction) / fraction)
+		}
+	}
+
+	select {
+	case r.events <- event:
+	default:
+		// a publish is still pending; drop this sample
+	}
+}
+
+func (r *progressReporter) Close() {
+	close(r.events)
+	<-r.finished
+}
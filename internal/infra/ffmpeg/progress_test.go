@@ -0,0 +1,48 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProgress(t *testing.T) {
+	input := strings.Join([]string{
+		"frame=12",
+		"fps=24.0",
+		"out_time_us=500000",
+		"out_time=00:00:00.500000",
+		"progress=continue",
+		"frame=30",
+		"out_time_ms=1250000",
+		"progress=continue",
+		"frame=abc",
+		"out_time_us=N/A",
+		"progress=continue",
+		"frame=48",
+		"out_time_us=2000000",
+		"progress=end",
+	}, "\n")
+
+	var updates []progressUpdate
+	parseProgress(strings.NewReader(input), func(update progressUpdate) {
+		updates = append(updates, update)
+	})
+
+	expected := []progressUpdate{
+		{outTime: 500 * time.Millisecond, frames: 12},
+		{outTime: 1250 * time.Millisecond, frames: 30},
+		// unparsable values keep the previous ones
+		{outTime: 1250 * time.Millisecond, frames: 30},
+		{outTime: 2 * time.Second, frames: 48, done: true},
+	}
+
+	if len(updates) != len(expected) {
+		t.Fatalf("Expected %d updates, got %d", len(expected), len(updates))
+	}
+	for i := range expected {
+		if updates[i] != expected[i] {
+			t.Errorf("Update %d: expected %+v, got %+v", i, expected[i], updates[i])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	customerrors "upframer-worker/internal/domain/errors"
+)
+
+// runFFmpeg runs ffmpeg with machine-readable progress on stdout and
+// classifies a failed run from its stderr output. onStderr sees every stderr
+// line first and may consume it by returning true.
+func runFFmpeg(ctx context.Context, args []string, onProgress func(progressUpdate), onStderr func(string) bool) error {
+	args = append([]string{"-progress", "pipe:1", "-nostats"}, args...)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+
+	stderr := &stderrCollector{onLine: onStderr}
+	cmd.Stderr = stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error creating ffmpeg stdout pipe: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%w: error starting ffmpeg: %v", customerrors.ErrFFmpegProcessing, err)
+	}
+
+	parseProgress(stdout, func(update progressUpdate) {
+		if onProgress != nil {
+			onProgress(update)
+		}
+	})
+
+	err = cmd.Wait()
+	stderr.flush()
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return classifyFFmpegError(err, stderr)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package ffmpeg
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	showinfoFramePattern = regexp.MustCompile(`\bn:\s*\d+.*\bpts_time:\s*(-?[0-9.]+)`)
+	showinfoSizePattern  = regexp.MustCompile(`\bs:(\d+)x(\d+)`)
+)
+
+type frameInfo struct {
+	timestamp float64
+	width     int
+	height    int
+}
+
+// showinfoParser collects the per-frame lines the showinfo filter logs on
+// stderr. offset is added to every timestamp, since input seeking restarts
+// them at zero.
+type showinfoParser struct {
+	offset float64
+	frames []frameInfo
+}
+
+// parse is a stderrCollector.onLine hook; it consumes every showinfo line so
+// they don't crowd real errors out of the stderr excerpt.
+func (p *showinfoParser) parse(line string) bool {
+	if !strings.Contains(line, "Parsed_showinfo") {
+		return false
+	}
+
+	match := showinfoFramePattern.FindStringSubmatch(line)
+	if match == nil {
+		return true
+	}
+
+	timestamp, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return true
+	}
+
+	info := frameInfo{timestamp: p.offset + timestamp}
+	if size := showinfoSizePattern.FindStringSubmatch(line); size != nil {
+		info.width, _ = strconv.Atoi(size[1])
+		info.height, _ = strconv.Atoi(size[2])
+	}
+
+	p.frames = append(p.frames, info)
+	return true
+}
@@ -0,0 +1,39 @@
+package ffmpeg
+
+import "testing"
+
+func TestShowinfoParser(t *testing.T) {
+	parser := &showinfoParser{offset: 600}
+
+	lines := []struct {
+		line     string
+		consumed bool
+	}{
+		{"[Parsed_showinfo_2 @ 0x5581] config in time_base: 1/12800, frame_rate: 25/1", true},
+		{"[Parsed_showinfo_2 @ 0x5581] n:   0 pts:      0 pts_time:0       duration:512 fmt:yuvj420p s:1280x720 i:P iskey:1", true},
+		{"[Parsed_showinfo_2 @ 0x5581] n:   1 pts:  12800 pts_time:1.5     duration:512 fmt:yuvj420p s:640x360 i:P iskey:0", true},
+		{"[Parsed_showinfo_2 @ 0x5581] n:   2 pts: -512 pts_time:-0.04", true},
+		{"[mjpeg @ 0x5590] Invalid data found when processing input", false},
+	}
+
+	for _, l := range lines {
+		if consumed := parser.parse(l.line); consumed != l.consumed {
+			t.Errorf("Expected consumed=%v for %q", l.consumed, l.line)
+		}
+	}
+
+	expected := []frameInfo{
+		{timestamp: 600, width: 1280, height: 720},
+		{timestamp: 601.5, width: 640, height: 360},
+		{timestamp: 599.96},
+	}
+
+	if len(parser.frames) != len(expected) {
+		t.Fatalf("Expected %d frames, got %d", len(expected), len(parser.frames))
+	}
+	for i := range expected {
+		if parser.frames[i] != expected[i] {
+			t.Errorf("Frame %d: expected %+v, got %+v", i, expected[i], parser.frames[i])
+		}
+	}
+}
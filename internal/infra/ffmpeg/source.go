@@ -0,0 +1,60 @@
+package ffmpeg
+
+import (
+	"net/url"
+	"strings"
+)
+
+// objectKey reports whether videoURL points at an object in storage and, if
+// so, returns its key. It understands s3://bucket/key, AWS virtual-hosted
+// (bucket.s3.region.amazonaws.com/key) and path-style
+// (s3.region.amazonaws.com/bucket/key) URLs, and URLs on the custom endpoint
+// in either addressing style. Other hosts are never taken for storage, even
+// when named like s3.example.com. The key is empty when the URL names no
+// object. The bucket in the URL is dropped: objects are always read from the
+// configured bucket.
+func objectKey(videoURL, endpoint string) (string, bool) {
+	u, err := url.Parse(videoURL)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+
+	if u.Scheme == "s3" {
+		return strings.TrimPrefix(u.Path, "/"), true
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+
+	host := strings.ToLower(u.Host)
+
+	if endpoint, err := url.Parse(endpoint); err == nil && endpoint.Host != "" {
+		endpointHost := strings.ToLower(endpoint.Host)
+		switch {
+		case host == endpointHost:
+			return pathStyleKey(u.Path), true
+		case strings.HasSuffix(host, "."+endpointHost):
+			return strings.TrimPrefix(u.Path, "/"), true
+		}
+	}
+
+	hostname := strings.ToLower(u.Hostname())
+	if !strings.HasSuffix(hostname, ".amazonaws.com") && !strings.HasSuffix(hostname, ".amazonaws.com.cn") {
+		return "", false
+	}
+
+	switch {
+	case strings.HasPrefix(hostname, "s3.") || strings.HasPrefix(hostname, "s3-"):
+		return pathStyleKey(u.Path), true
+	case strings.Contains(hostname, ".s3.") || strings.Contains(hostname, ".s3-"):
+		return strings.TrimPrefix(u.Path, "/"), true
+	}
+
+	return "", false
+}
+
+// pathStyleKey strips the leading bucket segment from a path-style path.
+func pathStyleKey(urlPath string) string {
+	_, key, _ := strings.Cut(strings.TrimPrefix(urlPath, "/"), "/")
+	return key
+}
@@ -0,0 +1,39 @@
+package ffmpeg
+
+import "testing"
+
+func TestObjectKey(t *testing.T) {
+	const minio = "http://localhost:9000"
+
+	tests := []struct {
+		name     string
+		url      string
+		endpoint string
+		key      string
+		ok       bool
+	}{
+		{"virtual-hosted", "https://bucket.s3.us-east-1.amazonaws.com/uploads/video.mp4", "", "uploads/video.mp4", true},
+		{"virtual-hosted legacy region", "https://bucket.s3-eu-west-1.amazonaws.com/video.mp4", "", "video.mp4", true},
+		{"virtual-hosted global", "https://bucket.s3.amazonaws.com/uploads/video.mp4", "", "uploads/video.mp4", true},
+		{"escaped key and query", "https://bucket.s3.us-east-1.amazonaws.com/uploads/my%20video.mp4?X-Amz-Expires=60", "", "uploads/my video.mp4", true},
+		{"path-style", "https://s3.us-east-1.amazonaws.com/bucket/uploads/video.mp4", "", "uploads/video.mp4", true},
+		{"path-style global", "https://s3.amazonaws.com/bucket/video.mp4", "", "video.mp4", true},
+		{"path-style without key", "https://s3.amazonaws.com/bucket", "", "", true},
+		{"s3 scheme", "s3://bucket/uploads/video.mp4", "", "uploads/video.mp4", true},
+		{"custom endpoint", "http://localhost:9000/bucket/uploads/video.mp4", minio, "uploads/video.mp4", true},
+		{"custom endpoint subdomain", "http://bucket.localhost:9000/uploads/video.mp4", minio, "uploads/video.mp4", true},
+		{"custom endpoint other port", "http://localhost:9001/bucket/uploads/video.mp4", minio, "", false},
+		{"custom endpoint not configured", "http://localhost:9000/bucket/uploads/video.mp4", "", "", false},
+		{"unrelated s3 host", "https://s3.example.com/x/y.mp4", "", "", false},
+		{"unrelated s3 subdomain", "https://cdn.s3.example.com/y.mp4", minio, "", false},
+		{"plain https", "https://example.com/videos/video.mp4", minio, "", false},
+		{"local path", "/tmp/video.mp4", minio, "", false},
+	}
+
+	for _, tt := range tests {
+		key, ok := objectKey(tt.url, tt.endpoint)
+		if key != tt.key || ok != tt.ok {
+			t.Errorf("%s: expected (%q, %v), got (%q, %v)", tt.name, tt.key, tt.ok, key, ok)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"upframer-worker/internal/domain/entities"
+)
+
+const spriteTrackName = "thumbnails.vtt"
+
+type spriteSheets struct {
+	dir    string
+	sheets []string
+	track  string
+	opts   entities.SpriteOptions
+}
+
+// generateSprites tiles the frames into sprite sheets of opts.Columns by
+// opts.Rows and writes a WebVTT track mapping each frame's time range to its
+// tile with a #xywh= fragment. Sheet names in the track are relative, so the
+// track has to be stored next to the sheets.
+func generateSprites(ctx context.Context, framesDir, spriteDir, format string, frames []entities.Frame, opts entities.SpriteOptions, metadata *entities.VideoMetadata) (*spriteSheets, error) {
+	if err := os.MkdirAll(spriteDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating sprite directory: %v", err)
+	}
+
+	if opts.TileHeight == 0 {
+		opts.TileHeight = tileHeight(opts.TileWidth, frames, metadata)
+	}
+	if err := opts.ValidateSheet(); err != nil {
+		return nil, err
+	}
+
+	result := &spriteSheets{
+		dir:   spriteDir,
+		track: spriteTrackName,
+		opts:  opts,
+	}
+
+	if len(frames) > 0 {
+		args := []string{
+			"-framerate", "1",
+			"-i", filepath.Join(framesDir, "frame_%04d."+format),
+			"-vf", fmt.Sprintf("scale=%d:%d,tile=%dx%d", opts.TileWidth, opts.TileHeight, opts.Columns, opts.Rows),
+			"-q:v", "3",
+			"-y",
+			filepath.Join(spriteDir, "sprite_%03d.jpg"),
+		}
+
+		if err := runFFmpeg(ctx, args, nil, nil); err != nil {
+			return nil, err
+		}
+
+		entries, err := os.ReadDir(spriteDir)
+		if err != nil {
+			return nil, fmt.Errorf("error reading sprite directory: %v", err)
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), "sprite_") {
+				result.sheets = append(result.sheets, entry.Name())
+			}
+		}
+	}
+
+	track := buildSpriteTrack(frames, result.sheets, opts, metadata.Duration)
+	if err := os.WriteFile(filepath.Join(spriteDir, spriteTrackName), []byte(track), 0644); err != nil {
+		return nil, fmt.Errorf("error writing WebVTT track: %v", err)
+	}
+
+	return result, nil
+}
+
+// tileHeight keeps the frames' aspect ratio, rounded to an even height.
+func tileHeight(tileWidth int, frames []entities.Frame, metadata *entities.VideoMetadata) int {
+	width, height := metadata.Width, metadata.Height
+	if len(frames) > 0 && frames[0].Width > 0 && frames[0].Height > 0 {
+		width, height = frames[0].Width, frames[0].Height
+	}
+
+	if width <= 0 || height <= 0 {
+		return tileWidth * 9 / 16 / 2 * 2
+	}
+	return int(math.Round(float64(tileWidth)*float64(height)/float64(width)/2)) * 2
+}
+
+// buildSpriteTrack gives each frame a cue lasting until the next frame, the
+// last one until the end of the video.
+func buildSpriteTrack(frames []entities.Frame, sheets []string, opts entities.SpriteOptions, duration float64) string {
+	var track strings.Builder
+	track.WriteString("WEBVTT\n")
+
+	perSheet := opts.Columns * opts.Rows
+
+	for i, frame := range frames {
+		sheet := i / perSheet
+		if sheet >= len(sheets) {
+			break
+		}
+		tile := i % perSheet
+
+		end := duration
+		if i+1 < len(frames) {
+			end = frames[i+1].Timestamp
+		}
+		if end <= frame.Timestamp {
+			end = frame.Timestamp + 1
+		}
+
+		fmt.Fprintf(&track, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			formatCueTime(frame.Timestamp),
+			formatCueTime(end),
+			sheets[sheet],
+			tile%opts.Columns*opts.TileWidth,
+			tile/opts.Columns*opts.TileHeight,
+			opts.TileWidth,
+			opts.TileHeight,
+		)
+	}
+
+	return track.String()
+}
+
+func formatCueTime(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,164 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+	"upframer-worker/internal/domain/entities"
+)
+
+func TestBuildSpriteTrack(t *testing.T) {
+	opts := entities.SpriteOptions{Columns: 2, Rows: 2, TileWidth: 160, TileHeight: 90}
+	frames := []entities.Frame{
+		{Timestamp: 0},
+		{Timestamp: 2.5},
+		{Timestamp: 2.5},
+		{Timestamp: 10},
+		{Timestamp: 3661.25},
+	}
+
+	tests := []struct {
+		name     string
+		sheets   []string
+		duration float64
+		expected []string
+	}{
+		{
+			name:     "rolls over to the next sheet",
+			sheets:   []string{"sprite_001.jpg", "sprite_002.jpg"},
+			duration: 3700,
+			expected: []string{
+				"00:00:00.000 --> 00:00:02.500\nsprite_001.jpg#xywh=0,0,160,90",
+				"00:00:02.500 --> 00:00:03.500\nsprite_001.jpg#xywh=160,0,160,90",
+				"00:00:02.500 --> 00:00:10.000\nsprite_001.jpg#xywh=0,90,160,90",
+				"00:00:10.000 --> 01:01:01.250\nsprite_001.jpg#xywh=160,90,160,90",
+				"01:01:01.250 --> 01:01:40.000\nsprite_002.jpg#xywh=0,0,160,90",
+			},
+		},
+		{
+			name:     "stops when the sheets run out",
+			sheets:   []string{"sprite_001.jpg"},
+			duration: 3700,
+			expected: []string{
+				"00:00:00.000 --> 00:00:02.500\nsprite_001.jpg#xywh=0,0,160,90",
+				"00:00:02.500 --> 00:00:03.500\nsprite_001.jpg#xywh=160,0,160,90",
+				"00:00:02.500 --> 00:00:10.000\nsprite_001.jpg#xywh=0,90,160,90",
+				"00:00:10.000 --> 01:01:01.250\nsprite_001.jpg#xywh=160,90,160,90",
+			},
+		},
+		{
+			name:     "last cue lasts a second when the duration is unknown",
+			sheets:   []string{"sprite_001.jpg", "sprite_002.jpg"},
+			duration: 0,
+			expected: []string{
+				"00:00:00.000 --> 00:00:02.500\nsprite_001.jpg#xywh=0,0,160,90",
+				"00:00:02.500 --> 00:00:03.500\nsprite_001.jpg#xywh=160,0,160,90",
+				"00:00:02.500 --> 00:00:10.000\nsprite_001.jpg#xywh=0,90,160,90",
+				"00:00:10.000 --> 01:01:01.250\nsprite_001.jpg#xywh=160,90,160,90",
+				"01:01:01.250 --> 01:01:02.250\nsprite_002.jpg#xywh=0,0,160,90",
+			},
+		},
+		{
+			name:     "no sheets",
+			sheets:   nil,
+			duration: 3700,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		track := buildSpriteTrack(frames, tt.sheets, opts, tt.duration)
+
+		expected := "WEBVTT\n"
+		for _, cue := range tt.expected {
+			expected += "\n" + cue + "\n"
+		}
+
+		if track != expected {
+			t.Errorf("%s: expected track\n%s\ngot\n%s", tt.name, expected, track)
+		}
+	}
+}
+
+func TestBuildSpriteTrack_TileCoordinates(t *testing.T) {
+	opts := entities.SpriteOptions{Columns: 3, Rows: 2, TileWidth: 200, TileHeight: 112}
+	frames := make([]entities.Frame, 6)
+	for i := range frames {
+		frames[i].Timestamp = float64(i)
+	}
+
+	track := buildSpriteTrack(frames, []string{"sprite_001.jpg"}, opts, 6)
+
+	expected := []string{
+		"#xywh=0,0,200,112",
+		"#xywh=200,0,200,112",
+		"#xywh=400,0,200,112",
+		"#xywh=0,112,200,112",
+		"#xywh=200,112,200,112",
+		"#xywh=400,112,200,112",
+	}
+
+	var fragments []string
+	for _, line := range strings.Split(track, "\n") {
+		if _, fragment, ok := strings.Cut(line, "sprite_001.jpg"); ok {
+			fragments = append(fragments, fragment)
+		}
+	}
+
+	if len(fragments) != len(expected) {
+		t.Fatalf("Expected %d cues, got %d", len(expected), len(fragments))
+	}
+	for i := range expected {
+		if fragments[i] != expected[i] {
+			t.Errorf("Cue %d: expected %s, got %s", i, expected[i], fragments[i])
+		}
+	}
+}
+
+func TestFormatCueTime(t *testing.T) {
+	tests := []struct {
+		seconds  float64
+		expected string
+	}{
+		{0, "00:00:00.000"},
+		{0.0004, "00:00:00.000"},
+		{0.0005, "00:00:00.001"},
+		{59.999, "00:00:59.999"},
+		{61.5, "00:01:01.500"},
+		{3661.25, "01:01:01.250"},
+		{36000, "10:00:00.000"},
+	}
+
+	for _, tt := range tests {
+		if result := formatCueTime(tt.seconds); result != tt.expected {
+			t.Errorf("Expected %v to format as %s, got %s", tt.seconds, tt.expected, result)
+		}
+	}
+}
+
+func TestTileHeight(t *testing.T) {
+	landscape := &entities.VideoMetadata{Width: 1920, Height: 1080}
+	unknown := &entities.VideoMetadata{}
+
+	tests := []struct {
+		name      string
+		tileWidth int
+		frames    []entities.Frame
+		metadata  *entities.VideoMetadata
+		expected  int
+	}{
+		{"from the frames", 160, []entities.Frame{{Width: 1280, Height: 720}}, unknown, 90},
+		{"frames win over metadata", 160, []entities.Frame{{Width: 1080, Height: 1920}}, landscape, 284},
+		{"from the metadata without frames", 160, nil, landscape, 90},
+		{"from the metadata when the frames have no size", 160, []entities.Frame{{}}, landscape, 90},
+		{"rounded to an even height", 150, nil, &entities.VideoMetadata{Width: 640, Height: 480}, 112},
+		{"rounded up to an even height", 154, nil, &entities.VideoMetadata{Width: 640, Height: 480}, 116},
+		{"unknown size falls back to 16:9", 160, nil, unknown, 90},
+		{"16:9 fallback stays even", 170, nil, unknown, 94},
+	}
+
+	for _, tt := range tests {
+		if result := tileHeight(tt.tileWidth, tt.frames, tt.metadata); result != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, result)
+		}
+	}
+}
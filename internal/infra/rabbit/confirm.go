@@ -0,0 +1,116 @@
+package rabbit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	customerrors "upframer-worker/internal/domain/errors"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	ErrPublishTimeout = errors.New("timed out waiting for broker confirmation")
+	ErrPublishNacked  = errors.New("message rejected by broker")
+	ErrUnroutable     = errors.New("message returned as unroutable")
+)
+
+type PublishError struct {
+	Exchange   string
+	RoutingKey string
+	Err        error
+}
+
+func (e *PublishError) Error() string {
+	return fmt.Sprintf("error publishing to exchange %q with routing key %q: %v", e.Exchange, e.RoutingKey, e.Err)
+}
+
+func (e *PublishError) Unwrap() []error {
+	return []error{e.Err, customerrors.ErrMessagePublishing}
+}
+
+// PublishWithConfirm publishes a mandatory message on the confirm-mode channel
+// and blocks until the broker acks it, returns it or the timeout expires.
+func (r *RabbitMQ) PublishWithConfirm(ctx context.Context, exchange, routingKey string, msg amqp091.Publishing) error {
+	r.publishMu.Lock()
+	defer r.publishMu.Unlock()
+
+	publishErr := func(err error) error {
+		return &PublishError{Exchange: exchange, RoutingKey: routingKey, Err: err}
+	}
+
+	r.mu.RLock()
+	ch, returns, state := r.publishChannel, r.returns, r.state
+	r.mu.RUnlock()
+
+	if state != StateConnected || ch == nil {
+		return publishErr(ErrConnectionClosed)
+	}
+
+	if msg.MessageId == "" {
+		msg.MessageId = newMessageId()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.confirmTimeout)
+	defer cancel()
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, true, false, msg)
+	if err != nil {
+		return publishErr(err)
+	}
+
+	select {
+	case <-confirmation.Done():
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return publishErr(ErrPublishTimeout)
+		}
+		return publishErr(ctx.Err())
+	}
+
+	// The broker sends basic.return before the ack, so by now any return for
+	// this message is already buffered.
+drain:
+	for {
+		select {
+		case ret := <-returns:
+			if ret.MessageId == msg.MessageId {
+				return publishErr(fmt.Errorf("%w: %d %s", ErrUnroutable, ret.ReplyCode, ret.ReplyText))
+			}
+		default:
+			break drain
+		}
+	}
+
+	if !confirmation.Acked() {
+		return publishErr(ErrPublishNacked)
+	}
+
+	return nil
+}
+
+// PublishTransient publishes on the consume channel without confirms, for
+// messages that may be lost. It doesn't take publishMu, so it never waits for
+// a confirmed publish.
+func (r *RabbitMQ) PublishTransient(ctx context.Context, exchange, routingKey string, msg amqp091.Publishing) error {
+	ch, err := r.Channel()
+	if err != nil {
+		return &PublishError{Exchange: exchange, RoutingKey: routingKey, Err: err}
+	}
+
+	if err := ch.PublishWithContext(ctx, exchange, routingKey, false, false, msg); err != nil {
+		return &PublishError{Exchange: exchange, RoutingKey: routingKey, Err: err}
+	}
+	return nil
+}
+
+func newMessageId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -3,98 +3,208 @@ package rabbit
 import (
 	"fmt"
 	"log"
+	"os"
+	"sync"
 
 	"github.com/rabbitmq/amqp091-go"
 )
 
-func (r *RabbitMQ) ConsumeRabbitMQQueue(queueName string) (<-chan amqp091.Delivery, error) {
+// consumer outlives the channels it consumes from. Each reconnect starts a
+// new forwarder; out is closed once the consumer is stopped and every
+// forwarder, including those of earlier channels, has exited.
+type consumer struct {
+	queueName  string
+	prefetch   int
+	tag        string
+	out        chan amqp091.Delivery
+	forwarders int
+	stopped    bool
+	closeOnce  sync.Once
+}
 
-	_, err := r.Channel.QueueDeclare(queueName, true, false, false, false, nil)
+func (c *consumer) closeOutput() {
+	c.closeOnce.Do(func() { close(c.out) })
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("error declaring queue: %v", err)
+func (r *RabbitMQ) releaseForwarder(c *consumer) {
+	r.mu.Lock()
+	c.forwarders--
+	closeOut := c.stopped && c.forwarders == 0
+	r.mu.Unlock()
+
+	if closeOut {
+		c.closeOutput()
 	}
+}
 
-	err = r.Channel.Qos(1, 0, false)
+// ConsumeRabbitMQQueue returns a delivery channel that survives reconnects;
+// it is only closed by StopConsuming or CloseConnection.
+func (r *RabbitMQ) ConsumeRabbitMQQueue(queueName string, prefetch int) (<-chan amqp091.Delivery, error) {
+	ch, err := r.Channel()
 	if err != nil {
-		return nil, fmt.Errorf("error configuring QoS: %v", err)
+		return nil, err
 	}
 
-	msgs, err := r.Channel.Consume(queueName, "", false, false, false, false, nil)
+	hostname, _ := os.Hostname()
 
-	if err != nil {
-		return nil, fmt.Errorf("error consuming the queue: %v", err)
+	c := &consumer{
+		queueName: queueName,
+		prefetch:  prefetch,
+		tag:       fmt.Sprintf("upframer-worker-%s-%d", hostname, os.Getpid()),
+		out:       make(chan amqp091.Delivery),
+	}
+
+	r.mu.Lock()
+	r.consumer = c
+	r.mu.Unlock()
+
+	if err := r.startConsumer(ch, c); err != nil {
+		r.mu.Lock()
+		r.consumer = nil
+		r.mu.Unlock()
+		return nil, err
 	}
 
 	log.Println("Waiting for messages...")
-	return msgs, err
+	return c.out, nil
 }
 
-func (r *RabbitMQ) SetupDLQ(queueName string) error {
-	dlqName := queueName + ".dlq"
-	dlqExchangeName := queueName + ".dlq.exchange"
+func (r *RabbitMQ) startConsumer(ch *amqp091.Channel, c *consumer) error {
+	r.rpcMu.Lock()
+	defer r.rpcMu.Unlock()
+
+	_, err := ch.QueueDeclare(c.queueName, true, false, false, false, nil)
 
-	err := r.Channel.ExchangeDeclare(
-		dlqExchangeName,
-		"direct",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
 	if err != nil {
-		return fmt.Errorf("error declaring DLQ exchange: %v", err)
+		return fmt.Errorf("error declaring queue: %v", err)
 	}
 
-	_, err = r.Channel.QueueDeclare(
-		dlqName,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+	err = ch.Qos(c.prefetch, 0, false)
 	if err != nil {
-		return fmt.Errorf("error declaring DLQ: %v", err)
+		return fmt.Errorf("error configuring QoS: %v", err)
 	}
 
-	err = r.Channel.QueueBind(
-		dlqName,
-		dlqName,
-		dlqExchangeName,
-		false,
-		nil,
-	)
+	// registered before consuming, so a concurrent StopConsuming leaves out
+	// open for this forwarder
+	r.mu.Lock()
+	if c.stopped {
+		r.mu.Unlock()
+		return nil
+	}
+	c.forwarders++
+	r.mu.Unlock()
+
+	msgs, err := ch.Consume(c.queueName, c.tag, false, false, false, false, nil)
+
 	if err != nil {
-		return fmt.Errorf("error binding DLQ: %v", err)
+		r.releaseForwarder(c)
+		return fmt.Errorf("error consuming the queue: %v", err)
 	}
 
-	log.Printf("DLQ setup complete: %s", dlqName)
+	r.mu.Lock()
+	stopped := c.stopped
+	r.mu.Unlock()
+
+	// StopConsuming may have cancelled the tag before the broker knew it
+	if stopped {
+		if err := ch.Cancel(c.tag, false); err != nil {
+			log.Printf("Error cancelling consumer: %v", err)
+		}
+	}
+
+	go r.forward(c, msgs)
 	return nil
 }
 
-func (r *RabbitMQ) RequeuWithRetryCount(queueName string, message []byte, retryCount int32) error {
-	headers := amqp091.Table{
-		"x-retry-count": retryCount + 1,
+func (r *RabbitMQ) forward(c *consumer, msgs <-chan amqp091.Delivery) {
+	defer r.releaseForwarder(c)
+
+	for msg := range msgs {
+		select {
+		case c.out <- msg:
+		case <-r.done:
+			return
+		}
 	}
+}
 
-	err := r.Channel.Publish(
-		"",
-		queueName,
-		false,
-		false,
-		amqp091.Publishing{
-			ContentType:  "application/json",
-			Body:         message,
-			DeliveryMode: amqp091.Persistent,
-			Headers:      headers,
-		},
-	)
+func (r *RabbitMQ) StopConsuming() error {
+	r.mu.Lock()
+	c := r.consumer
+	if c == nil {
+		r.mu.Unlock()
+		return nil
+	}
+	c.stopped = true
+	forwarders := c.forwarders
+	ch := r.channel
+	r.mu.Unlock()
+
+	if forwarders == 0 {
+		c.closeOutput()
+		return nil
+	}
 
+	// the forwarders close out once their deliveries stop; if cancelling
+	// failed the channel is gone and they stop anyway
+	r.rpcMu.Lock()
+	err := ch.Cancel(c.tag, false)
+	r.rpcMu.Unlock()
 	if err != nil {
-		return fmt.Errorf("error requeuing message: %v", err)
+		return fmt.Errorf("error cancelling consumer: %v", err)
 	}
 
+	log.Printf("Stopped consuming (consumer tag %s)", c.tag)
+	return nil
+}
+
+func (r *RabbitMQ) SetupDLQ(queueName string) error {
+	dlqName := queueName + ".dlq"
+	dlqExchangeName := queueName + ".dlq.exchange"
+
+	err := r.declare(func(ch *amqp091.Channel) error {
+		err := ch.ExchangeDeclare(
+			dlqExchangeName,
+			"direct",
+			true,
+			false,
+			false,
+			false,
+			nil,
+		)
+		if err != nil {
+			return fmt.Errorf("error declaring DLQ exchange: %v", err)
+		}
+
+		_, err = ch.QueueDeclare(
+			dlqName,
+			true,
+			false,
+			false,
+			false,
+			nil,
+		)
+		if err != nil {
+			return fmt.Errorf("error declaring DLQ: %v", err)
+		}
+
+		err = ch.QueueBind(
+			dlqName,
+			dlqName,
+			dlqExchangeName,
+			false,
+			nil,
+		)
+		if err != nil {
+			return fmt.Errorf("error binding DLQ: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("DLQ setup complete: %s", dlqName)
 	return nil
 }
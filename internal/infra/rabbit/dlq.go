@@ -0,0 +1,272 @@
+package rabbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+type DLQMessage struct {
+	MessageId     string                 `json:"messageId,omitempty"`
+	JobId         string                 `json:"jobId,omitempty"`
+	OriginalQueue string                 `json:"originalQueue,omitempty"`
+	FailureReason string                 `json:"failureReason,omitempty"`
+	FailureDetail string                 `json:"failureDetail,omitempty"`
+	RetryCount    int32                  `json:"retryCount"`
+	Timestamp     *time.Time             `json:"timestamp,omitempty"`
+	Headers       map[string]interface{} `json:"headers,omitempty"`
+	Body          json.RawMessage        `json:"body,omitempty"`
+	RawBody       string                 `json:"rawBody,omitempty"`
+}
+
+type DLQFilter struct {
+	Reason string
+	JobId  string
+}
+
+func (f DLQFilter) IsEmpty() bool {
+	return f.Reason == "" && f.JobId == ""
+}
+
+func (f DLQFilter) Matches(msg DLQMessage) bool {
+	if f.JobId != "" && msg.JobId != f.JobId {
+		return false
+	}
+	if f.Reason != "" && !strings.Contains(strings.ToLower(msg.FailureReason), strings.ToLower(f.Reason)) {
+		return false
+	}
+	return true
+}
+
+type DLQInspector struct {
+	client    *RabbitMQ
+	queueName string
+}
+
+func NewDLQInspector(client *RabbitMQ, queueName string) *DLQInspector {
+	return &DLQInspector{
+		client:    client,
+		queueName: queueName,
+	}
+}
+
+func (i *DLQInspector) dlqName() string {
+	return i.queueName + ".dlq"
+}
+
+func (i *DLQInspector) List(filter DLQFilter, limit int) ([]DLQMessage, error) {
+	var messages []DLQMessage
+
+	err := i.visit(filter, limit, func(msg DLQMessage, _ amqp091.Delivery) (bool, error) {
+		messages = append(messages, msg)
+		return false, nil
+	}, nil)
+
+	return messages, err
+}
+
+func (i *DLQInspector) Replay(filter DLQFilter, limit int) (int, error) {
+	replayed := 0
+
+	err := i.visit(filter, limit, func(msg DLQMessage, delivery amqp091.Delivery) (bool, error) {
+		target := msg.OriginalQueue
+		if target == "" {
+			target = i.queueName
+		}
+
+		contentType := delivery.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+
+		err := i.client.PublishWithConfirm(
+			context.Background(),
+			"",
+			target,
+			amqp091.Publishing{
+				ContentType:  contentType,
+				Body:         delivery.Body,
+				DeliveryMode: amqp091.Persistent,
+				Headers: amqp091.Table{
+					"x-retry-count": int32(0),
+				},
+			},
+		)
+		if err != nil {
+			return false, fmt.Errorf("error replaying job %s: %w", msg.JobId, err)
+		}
+
+		replayed++
+		return true, nil
+	}, nil)
+
+	return replayed, err
+}
+
+func (i *DLQInspector) Purge(filter DLQFilter, limit int) (int, error) {
+	if filter.IsEmpty() && limit <= 0 {
+		ch, err := i.client.Channel()
+		if err != nil {
+			return 0, err
+		}
+
+		purged, err := ch.QueuePurge(i.dlqName(), false)
+		if err != nil {
+			return 0, fmt.Errorf("error purging DLQ: %v", err)
+		}
+		return purged, nil
+	}
+
+	purged := 0
+	err := i.visit(filter, limit, func(DLQMessage, amqp091.Delivery) (bool, error) {
+		purged++
+		return true, nil
+	}, nil)
+
+	return purged, err
+}
+
+// Export writes the matching messages with write. With remove, they are only
+// acked once flush has made the export durable, so a failed export leaves
+// them in the DLQ.
+func (i *DLQInspector) Export(filter DLQFilter, limit int, remove bool, write func(DLQMessage) error, flush func() error) (int, error) {
+	exported := 0
+
+	err := i.visit(filter, limit, func(msg DLQMessage, _ amqp091.Delivery) (bool, error) {
+		if err := write(msg); err != nil {
+			return false, err
+		}
+		exported++
+		return remove, nil
+	}, flush)
+
+	return exported, err
+}
+
+// visit fetches every message in the DLQ without acking, so each one is seen
+// once. Messages for which fn returns true are acked (removed); all others
+// are returned to the DLQ when the walk finishes. With commit, the acks wait
+// until the walk is over and commit succeeds.
+func (i *DLQInspector) visit(filter DLQFilter, limit int, fn func(DLQMessage, amqp091.Delivery) (bool, error), commit func() error) error {
+	ch, err := i.client.Channel()
+	if err != nil {
+		return err
+	}
+
+	// removed only holds deliveries waiting on commit; if commit is never
+	// reached or fails, they go back to the DLQ with the rest
+	var pending, removed []amqp091.Delivery
+	defer func() {
+		for _, delivery := range append(pending, removed...) {
+			delivery.Nack(false, true)
+		}
+	}()
+
+	matched := 0
+	for limit <= 0 || matched < limit {
+		delivery, ok, err := ch.Get(i.dlqName(), false)
+		if err != nil {
+			return fmt.Errorf("error reading DLQ: %v", err)
+		}
+		if !ok {
+			break
+		}
+
+		msg := newDLQMessage(delivery)
+		if !filter.Matches(msg) {
+			pending = append(pending, delivery)
+			continue
+		}
+		matched++
+
+		remove, err := fn(msg, delivery)
+		if err != nil {
+			pending = append(pending, delivery)
+			return err
+		}
+
+		if !remove {
+			pending = append(pending, delivery)
+			continue
+		}
+
+		if commit != nil {
+			removed = append(removed, delivery)
+			continue
+		}
+
+		if err := delivery.Ack(false); err != nil {
+			return fmt.Errorf("error acknowledging DLQ message: %v", err)
+		}
+	}
+
+	if commit == nil {
+		return nil
+	}
+
+	if err := commit(); err != nil {
+		return err
+	}
+
+	acked := removed
+	removed = nil
+	for _, delivery := range acked {
+		if err := delivery.Ack(false); err != nil {
+			return fmt.Errorf("error acknowledging DLQ message: %v", err)
+		}
+	}
+	return nil
+}
+
+func newDLQMessage(delivery amqp091.Delivery) DLQMessage {
+	msg := DLQMessage{
+		MessageId: delivery.MessageId,
+		Headers:   map[string]interface{}{},
+	}
+
+	if !delivery.Timestamp.IsZero() {
+		timestamp := delivery.Timestamp
+		msg.Timestamp = &timestamp
+	}
+
+	for key, value := range delivery.Headers {
+		switch v := value.(type) {
+		case string, bool, int, int8, int16, int32, int64, float32, float64, nil:
+			msg.Headers[key] = v
+		default:
+			msg.Headers[key] = fmt.Sprint(v)
+		}
+	}
+
+	if value, ok := delivery.Headers["x-original-queue"].(string); ok {
+		msg.OriginalQueue = value
+	}
+	if value, ok := delivery.Headers["x-failure-reason"].(string); ok {
+		msg.FailureReason = value
+	}
+	if value, ok := delivery.Headers["x-failure-detail"].(string); ok {
+		msg.FailureDetail = value
+	}
+	if value, ok := delivery.Headers["x-retry-count"].(int32); ok {
+		msg.RetryCount = value
+	}
+
+	if json.Valid(delivery.Body) {
+		msg.Body = delivery.Body
+
+		var job struct {
+			JobId string `json:"jobId"`
+		}
+		if err := json.Unmarshal(delivery.Body, &job); err == nil {
+			msg.JobId = job.JobId
+		}
+	} else {
+		msg.RawBody = string(delivery.Body)
+	}
+
+	return msg
+}
@@ -1,9 +1,11 @@
 package rabbit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
 	"upframer-worker/internal/domain/entities"
 
 	"github.com/rabbitmq/amqp091-go"
@@ -11,53 +13,104 @@ import (
 
 type RabbitPublisher struct {
 	client *RabbitMQ
+
+	mu       sync.Mutex
+	declared map[string]bool
 }
 
 func NewRabbitPublisher(client *RabbitMQ) *RabbitPublisher {
 	return &RabbitPublisher{
-		client: client,
+		client:   client,
+		declared: make(map[string]bool),
 	}
 }
 
-func (p *RabbitPublisher) Publish(queueName string, result *entities.ProcessingResult) error {
-	_, err := p.client.Channel.QueueDeclare(queueName, true, false, false, false, nil)
+// DeclareQueues declares the queues results and progress go to up front, so
+// publishing never runs a declare on the consume channel, where it could race
+// with the consumer's own calls.
+func (p *RabbitPublisher) DeclareQueues(queueNames ...string) error {
+	for _, queueName := range queueNames {
+		if err := p.declareQueue(queueName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// declareQueue declares queueName unless it already was; the declaration is
+// replayed on reconnect like the rest of the topology.
+func (p *RabbitPublisher) declareQueue(queueName string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
+	if p.declared[queueName] {
+		return nil
+	}
+
+	err := p.client.declare(func(ch *amqp091.Channel) error {
+		if _, err := ch.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("error declaring queue: %v", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error declaring queue: %v", err)
+		return err
 	}
 
-	notification := map[string]interface{}{
-		"outputPath": result.OutputPath,
-		"status":     result.Status,
-		"jobId":      result.JobId,
+	p.declared[queueName] = true
+	return nil
+}
+
+func (p *RabbitPublisher) Publish(queueName string, result *entities.ProcessingResult) error {
+	if err := p.declareQueue(queueName); err != nil {
+		return fmt.Errorf("error publishing result: %w", err)
 	}
 
-	messageJSON, err := json.Marshal(notification)
+	messageJSON, err := json.Marshal(newResultMessage(result))
 
 	if err != nil {
 		return fmt.Errorf("erro ao converter mensagem para JSON: %v", err)
 	}
 
-	err = p.client.Channel.Publish(
+	return p.client.PublishWithConfirm(
+		context.Background(),
 		"",
 		queueName,
-		false,
-		false,
 		amqp091.Publishing{
 			ContentType:  "application/json",
 			Body:         messageJSON,
 			DeliveryMode: amqp091.Persistent,
 		},
 	)
+}
+
+// PublishProgress doesn't wait for a confirmation: progress events are
+// throttled and superseded by the next one, and waiting would hold up result,
+// retry and DLQ publishes behind them.
+func (p *RabbitPublisher) PublishProgress(queueName string, event *entities.ProgressEvent) error {
+	if err := p.declareQueue(queueName); err != nil {
+		return fmt.Errorf("error publishing progress: %w", err)
+	}
+
+	messageJSON, err := json.Marshal(newProgressMessage(event))
 
 	if err != nil {
-		log.Fatal("Error while publ", err)
+		return fmt.Errorf("error encoding progress message: %v", err)
 	}
 
-	return nil
+	return p.client.PublishTransient(
+		context.Background(),
+		"",
+		queueName,
+		amqp091.Publishing{
+			ContentType:  "application/json",
+			Body:         messageJSON,
+			DeliveryMode: amqp091.Transient,
+		},
+	)
 }
 
-func (p *RabbitPublisher) PublishToDLQ(queueName string, originalMessage []byte, reason string, retryCount int32) error {
+func (p *RabbitPublisher) PublishToDLQ(queueName string, originalMessage []byte, reason, detail string, retryCount int32) error {
 	dlqExchangeName := queueName + ".dlq.exchange"
 	dlqRoutingKey := queueName + ".dlq"
 
@@ -67,11 +120,14 @@ func (p *RabbitPublisher) PublishToDLQ(queueName string, originalMessage []byte,
 		"x-retry-count":    retryCount,
 	}
 
-	err := p.client.Channel.Publish(
+	if detail != "" {
+		headers["x-failure-detail"] = detail
+	}
+
+	err := p.client.PublishWithConfirm(
+		context.Background(),
 		dlqExchangeName,
 		dlqRoutingKey,
-		false,
-		false,
 		amqp091.Publishing{
 			ContentType:  "application/json",
 			Body:         originalMessage,
@@ -81,7 +137,7 @@ func (p *RabbitPublisher) PublishToDLQ(queueName string, originalMessage []byte,
 	)
 
 	if err != nil {
-		return fmt.Errorf("error publishing to DLQ: %v", err)
+		return err
 	}
 
 	log.Printf("Message sent to DLQ. Reason: %s, Retry count: %d", reason, retryCount)
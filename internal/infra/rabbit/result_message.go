@@ -0,0 +1,173 @@
+package rabbit
+
+import (
+	"time"
+	"upframer-worker/internal/domain/entities"
+)
+
+type resultMessage struct {
+	OutputPath           string                `json:"outputPath"`
+	OutputKey            string                `json:"outputKey,omitempty"`
+	DownloadURL          string                `json:"downloadUrl,omitempty"`
+	DownloadURLExpiresAt *time.Time            `json:"downloadUrlExpiresAt,omitempty"`
+	Status               string                `json:"status"`
+	JobId                string                `json:"jobId"`
+	FrameCount           int                   `json:"frameCount,omitempty"`
+	Frames               []frameMessage        `json:"frames,omitempty"`
+	Video                *videoMetadataMessage `json:"video,omitempty"`
+	Archive              *archiveMessage       `json:"archive,omitempty"`
+	Sprites              *spritesMessage       `json:"sprites,omitempty"`
+	Preview              *previewMessage       `json:"preview,omitempty"`
+	FrameObjects         *frameObjectsMessage  `json:"frameObjects,omitempty"`
+	StartedAt            *time.Time            `json:"startedAt,omitempty"`
+	FinishedAt           *time.Time            `json:"finishedAt,omitempty"`
+	WorkerId             string                `json:"workerId,omitempty"`
+	Error                *errorMessage         `json:"error,omitempty"`
+}
+
+type errorMessage struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type frameMessage struct {
+	Index     int     `json:"index"`
+	File      string  `json:"file"`
+	Timestamp float64 `json:"timestamp"`
+}
+
+type videoMetadataMessage struct {
+	Duration float64 `json:"duration"`
+	Width    int     `json:"width"`
+	Height   int     `json:"height"`
+	Codec    string  `json:"codec"`
+	Size     int64   `json:"size"`
+}
+
+type archiveMessage struct {
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	Format      string `json:"format,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type spritesMessage struct {
+	TrackURL   string   `json:"trackUrl"`
+	Sheets     []string `json:"sheets"`
+	Columns    int      `json:"columns"`
+	Rows       int      `json:"rows"`
+	TileWidth  int      `json:"tileWidth"`
+	TileHeight int      `json:"tileHeight"`
+}
+
+type previewMessage struct {
+	URL    string `json:"url"`
+	Format string `json:"format"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type frameObjectsMessage struct {
+	Prefix      string `json:"prefix"`
+	ManifestURL string `json:"manifestUrl"`
+	Count       int    `json:"count"`
+}
+
+func newResultMessage(result *entities.ProcessingResult) resultMessage {
+	message := resultMessage{
+		OutputPath:           result.OutputPath,
+		OutputKey:            result.OutputKey,
+		DownloadURL:          result.DownloadURL,
+		DownloadURLExpiresAt: result.DownloadURLExpiresAt,
+		Status:               result.Status,
+		JobId:                result.JobId,
+		FrameCount:           result.FrameCount,
+		StartedAt:            result.StartedAt,
+		FinishedAt:           result.FinishedAt,
+		WorkerId:             result.WorkerId,
+	}
+
+	for _, frame := range result.Frames {
+		message.Frames = append(message.Frames, frameMessage{
+			Index:     frame.Index,
+			File:      frame.File,
+			Timestamp: frame.Timestamp,
+		})
+	}
+
+	if result.Video != nil {
+		message.Video = &videoMetadataMessage{
+			Duration: result.Video.Duration,
+			Width:    result.Video.Width,
+			Height:   result.Video.Height,
+			Codec:    result.Video.Codec,
+			Size:     result.Video.Size,
+		}
+	}
+
+	if result.Archive != nil {
+		message.Archive = &archiveMessage{
+			Size:        result.Archive.Size,
+			SHA256:      result.Archive.Checksum,
+			Format:      result.Archive.Format,
+			ContentType: result.Archive.ContentType,
+		}
+	}
+
+	if result.Sprites != nil {
+		message.Sprites = &spritesMessage{
+			TrackURL:   result.Sprites.TrackURL,
+			Sheets:     result.Sprites.SheetURLs,
+			Columns:    result.Sprites.Columns,
+			Rows:       result.Sprites.Rows,
+			TileWidth:  result.Sprites.TileWidth,
+			TileHeight: result.Sprites.TileHeight,
+		}
+	}
+
+	if result.Preview != nil {
+		message.Preview = &previewMessage{
+			URL:    result.Preview.URL,
+			Format: result.Preview.Format,
+			Size:   result.Preview.Size,
+			SHA256: result.Preview.Checksum,
+		}
+	}
+
+	if result.FrameObjects != nil {
+		message.FrameObjects = &frameObjectsMessage{
+			Prefix:      result.FrameObjects.Prefix,
+			ManifestURL: result.FrameObjects.ManifestURL,
+			Count:       result.FrameObjects.Count,
+		}
+	}
+
+	if result.ErrorCode != "" {
+		message.Error = &errorMessage{
+			Code:    result.ErrorCode,
+			Message: result.ErrorMessage,
+		}
+	}
+
+	return message
+}
+
+type progressMessage struct {
+	JobId      string    `json:"jobId"`
+	Percent    float64   `json:"percent"`
+	Frames     int       `json:"frames"`
+	ETASeconds float64   `json:"etaSeconds"`
+	Timestamp  time.Time `json:"timestamp"`
+	WorkerId   string    `json:"workerId,omitempty"`
+}
+
+func newProgressMessage(event *entities.ProgressEvent) progressMessage {
+	return progressMessage{
+		JobId:      event.JobId,
+		Percent:    event.Percent,
+		Frames:     event.Frames,
+		ETASeconds: event.ETASeconds,
+		Timestamp:  event.Timestamp,
+		WorkerId:   event.WorkerId,
+	}
+}
@@ -0,0 +1,172 @@
+package rabbit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"upframer-worker/internal/domain/entities"
+)
+
+func encodeMessage(t *testing.T, message any) map[string]any {
+	t.Helper()
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	return decoded
+}
+
+func TestNewResultMessage_Completed(t *testing.T) {
+	startedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	finishedAt := startedAt.Add(90 * time.Second)
+	expiresAt := finishedAt.Add(24 * time.Hour)
+
+	message := encodeMessage(t, newResultMessage(&entities.ProcessingResult{
+		Status:               entities.StatusCompleted,
+		OutputPath:           "https://bucket.s3.amazonaws.com/results/frames_job-1.zip",
+		OutputKey:            "results/frames_job-1.zip",
+		DownloadURL:          "https://bucket.s3.amazonaws.com/results/frames_job-1.zip?X-Amz-Signature=abc",
+		DownloadURLExpiresAt: &expiresAt,
+		JobId:                "job-1",
+		FrameCount:           2,
+		Frames: []entities.Frame{
+			{Index: 1, File: "frame_0001.jpg", Timestamp: 0, Width: 1280, Height: 720},
+			{Index: 2, File: "frame_0002.jpg", Timestamp: 1.5},
+		},
+		Video:        &entities.VideoMetadata{Duration: 120.5, Width: 1920, Height: 1080, Codec: "h264", Size: 1048576},
+		Archive:      &entities.ArchiveMetadata{Size: 524288, Checksum: "abc123", Format: entities.ArchiveTarZst, ContentType: "application/zstd"},
+		Sprites:      &entities.SpriteMetadata{TrackURL: "thumbnails.vtt", SheetURLs: []string{"sprite_001.jpg"}, Columns: 10, Rows: 10, TileWidth: 160, TileHeight: 90},
+		Preview:      &entities.PreviewMetadata{URL: "preview.gif", Format: "gif", Size: 2048, Checksum: "def456"},
+		FrameObjects: &entities.FrameObjects{Prefix: "results/frames_job-1/", ManifestURL: "manifest.json", Count: 2},
+		StartedAt:    &startedAt,
+		FinishedAt:   &finishedAt,
+		WorkerId:     "worker-1",
+	}))
+
+	expected := map[string]any{
+		"status":               "completed",
+		"jobId":                "job-1",
+		"outputPath":           "https://bucket.s3.amazonaws.com/results/frames_job-1.zip",
+		"outputKey":            "results/frames_job-1.zip",
+		"downloadUrl":          "https://bucket.s3.amazonaws.com/results/frames_job-1.zip?X-Amz-Signature=abc",
+		"downloadUrlExpiresAt": "2025-01-03T03:05:35Z",
+		"frameCount":           float64(2),
+		"startedAt":            "2025-01-02T03:04:05Z",
+		"finishedAt":           "2025-01-02T03:05:35Z",
+		"workerId":             "worker-1",
+	}
+	for key, value := range expected {
+		if message[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, message[key])
+		}
+	}
+	if _, ok := message["error"]; ok {
+		t.Errorf("Expected no error in a completed result, got %v", message["error"])
+	}
+
+	nested := []struct {
+		object string
+		key    string
+		value  any
+	}{
+		{"video", "duration", 120.5},
+		{"video", "width", float64(1920)},
+		{"video", "codec", "h264"},
+		{"video", "size", float64(1048576)},
+		{"archive", "size", float64(524288)},
+		{"archive", "sha256", "abc123"},
+		{"archive", "format", "tar.zst"},
+		{"archive", "contentType", "application/zstd"},
+		{"sprites", "trackUrl", "thumbnails.vtt"},
+		{"sprites", "tileHeight", float64(90)},
+		{"preview", "url", "preview.gif"},
+		{"preview", "sha256", "def456"},
+		{"frameObjects", "prefix", "results/frames_job-1/"},
+		{"frameObjects", "manifestUrl", "manifest.json"},
+		{"frameObjects", "count", float64(2)},
+	}
+	for _, tt := range nested {
+		object, ok := message[tt.object].(map[string]any)
+		if !ok {
+			t.Errorf("Expected %s to be an object, got %v", tt.object, message[tt.object])
+			continue
+		}
+		if object[tt.key] != tt.value {
+			t.Errorf("Expected %s.%s %v, got %v", tt.object, tt.key, tt.value, object[tt.key])
+		}
+	}
+
+	sheets, _ := message["sprites"].(map[string]any)["sheets"].([]any)
+	if len(sheets) != 1 || sheets[0] != "sprite_001.jpg" {
+		t.Errorf("Expected sprites.sheets [sprite_001.jpg], got %v", sheets)
+	}
+
+	frames, _ := message["frames"].([]any)
+	if len(frames) != 2 {
+		t.Fatalf("Expected 2 frames, got %v", message["frames"])
+	}
+	frame := frames[1].(map[string]any)
+	if frame["index"] != float64(2) || frame["file"] != "frame_0002.jpg" || frame["timestamp"] != 1.5 {
+		t.Errorf("Expected frame 2 at 1.5s, got %v", frame)
+	}
+}
+
+func TestNewResultMessage_Failed(t *testing.T) {
+	message := encodeMessage(t, newResultMessage(&entities.ProcessingResult{
+		Status:       entities.StatusFailed,
+		JobId:        "job-2",
+		ErrorCode:    "CORRUPT_CONTAINER",
+		ErrorMessage: "corrupt or incomplete video container",
+	}))
+
+	if message["status"] != "failed" || message["jobId"] != "job-2" {
+		t.Errorf("Expected failed job-2, got %v", message)
+	}
+
+	// outputPath is always present, even when empty
+	if value, ok := message["outputPath"]; !ok || value != "" {
+		t.Errorf("Expected empty outputPath, got %v", value)
+	}
+
+	errorObject, ok := message["error"].(map[string]any)
+	if !ok || errorObject["code"] != "CORRUPT_CONTAINER" || errorObject["message"] != "corrupt or incomplete video container" {
+		t.Errorf("Expected error CORRUPT_CONTAINER, got %v", message["error"])
+	}
+
+	for _, key := range []string{"outputKey", "downloadUrl", "downloadUrlExpiresAt", "frameCount", "frames", "video", "archive", "sprites", "preview", "frameObjects"} {
+		if _, ok := message[key]; ok {
+			t.Errorf("Expected %s to be omitted, got %v", key, message[key])
+		}
+	}
+}
+
+func TestNewProgressMessage(t *testing.T) {
+	message := encodeMessage(t, newProgressMessage(&entities.ProgressEvent{
+		JobId:      "job-3",
+		Percent:    42.5,
+		Frames:     51,
+		ETASeconds: 38,
+		Timestamp:  time.Date(2025, 1, 2, 3, 4, 35, 0, time.UTC),
+		WorkerId:   "worker-1",
+	}))
+
+	expected := map[string]any{
+		"jobId":      "job-3",
+		"percent":    42.5,
+		"frames":     float64(51),
+		"etaSeconds": float64(38),
+		"timestamp":  "2025-01-02T03:04:35Z",
+		"workerId":   "worker-1",
+	}
+	for key, value := range expected {
+		if message[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, message[key])
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package rabbit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+type RetryPolicy struct {
+	BaseDelay   time.Duration
+	Multiplier  float64
+	Jitter      float64
+	MaxAttempts int
+}
+
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	return time.Duration(float64(p.BaseDelay) * math.Pow(p.Multiplier, float64(attempt-1)))
+}
+
+func (p RetryPolicy) jitteredDelay(attempt int) time.Duration {
+	delay := p.Delay(attempt)
+	if p.Jitter <= 0 {
+		return delay
+	}
+	factor := 1 + p.Jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(delay) * factor)
+}
+
+func (p RetryPolicy) maxDelay(attempt int) time.Duration {
+	return time.Duration(float64(p.Delay(attempt)) * (1 + math.Max(p.Jitter, 0)))
+}
+
+// Retry queues are named after their TTL so that changing the policy declares
+// new queues instead of conflicting with the arguments of existing ones.
+func (p RetryPolicy) retryQueueName(queueName string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%s", queueName, p.maxDelay(attempt).Round(time.Millisecond))
+}
+
+func (r *RabbitMQ) SetupRetry(queueName string, policy RetryPolicy) error {
+	err := r.declare(func(ch *amqp091.Channel) error {
+		for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+			_, err := ch.QueueDeclare(
+				policy.retryQueueName(queueName, attempt),
+				true,
+				false,
+				false,
+				false,
+				amqp091.Table{
+					"x-message-ttl":             policy.maxDelay(attempt).Milliseconds(),
+					"x-dead-letter-exchange":    "",
+					"x-dead-letter-routing-key": queueName,
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("error declaring retry queue for attempt %d: %v", attempt, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Retry setup complete: %d attempts, base delay %s, multiplier %g", policy.MaxAttempts, policy.BaseDelay, policy.Multiplier)
+	return nil
+}
+
+func (r *RabbitMQ) ScheduleRetry(queueName string, message []byte, retryCount int32, policy RetryPolicy) error {
+	attempt := int(retryCount) + 1
+	delay := policy.jitteredDelay(attempt)
+
+	headers := amqp091.Table{
+		"x-retry-count": retryCount + 1,
+	}
+
+	err := r.PublishWithConfirm(
+		context.Background(),
+		"",
+		policy.retryQueueName(queueName, attempt),
+		amqp091.Publishing{
+			ContentType:  "application/json",
+			Body:         message,
+			DeliveryMode: amqp091.Persistent,
+			Headers:      headers,
+			Expiration:   strconv.FormatInt(delay.Milliseconds(), 10),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Retry %d scheduled in %s", attempt, delay.Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,67 @@
+package rabbit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 5 * time.Second, Multiplier: 2, MaxAttempts: 3}
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{0, 5 * time.Second},
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if delay := policy.Delay(tt.attempt); delay != tt.expected {
+			t.Errorf("Attempt %d: expected %s, got %s", tt.attempt, tt.expected, delay)
+		}
+	}
+}
+
+func TestRetryPolicy_JitteredDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Second, Multiplier: 2, Jitter: 0.2}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.jitteredDelay(2)
+		if delay < 16*time.Second || delay > 24*time.Second {
+			t.Fatalf("Expected delay within ±20%% of 20s, got %s", delay)
+		}
+		if delay > policy.maxDelay(2) {
+			t.Fatalf("Expected delay %s to stay below the queue TTL %s", delay, policy.maxDelay(2))
+		}
+	}
+}
+
+func TestRetryPolicy_JitteredDelay_NoJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Second, Multiplier: 2}
+
+	if delay := policy.jitteredDelay(3); delay != 40*time.Second {
+		t.Errorf("Expected exactly 40s without jitter, got %s", delay)
+	}
+}
+
+func TestRetryPolicy_RetryQueueName(t *testing.T) {
+	tests := []struct {
+		policy   RetryPolicy
+		attempt  int
+		expected string
+	}{
+		{RetryPolicy{BaseDelay: 5 * time.Second, Multiplier: 2}, 1, "job-creation.retry.5s"},
+		{RetryPolicy{BaseDelay: 5 * time.Second, Multiplier: 2}, 3, "job-creation.retry.20s"},
+		{RetryPolicy{BaseDelay: 5 * time.Second, Multiplier: 2, Jitter: 0.2}, 2, "job-creation.retry.12s"},
+		{RetryPolicy{BaseDelay: 1500 * time.Millisecond, Multiplier: 1.5, Jitter: 0.1}, 2, "job-creation.retry.2.475s"},
+	}
+
+	for _, tt := range tests {
+		if name := tt.policy.retryQueueName("job-creation", tt.attempt); name != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, name)
+		}
+	}
+}
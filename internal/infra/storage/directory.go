@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"mime"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+type directoryFile struct {
+	path        string
+	name        string
+	contentType string
+}
+
+// listDirectory returns every file under dir with its slash-separated path
+// relative to dir and a content type guessed from the extension.
+func listDirectory(dir string) ([]directoryFile, error) {
+	var files []directoryFile
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		files = append(files, directoryFile{
+			path:        path,
+			name:        filepath.ToSlash(relPath),
+			contentType: contentType,
+		})
+		return nil
+	})
+
+	return files, err
+}
+
+// splitLast moves the files named in last, in that order, out of files.
+func splitLast(files []directoryFile, last []string) (rest, deferred []directoryFile) {
+	byName := make(map[string]directoryFile, len(last))
+	for _, file := range files {
+		if slices.Contains(last, file.name) {
+			byName[file.name] = file
+			continue
+		}
+		rest = append(rest, file)
+	}
+
+	for _, name := range last {
+		if file, ok := byName[name]; ok {
+			deferred = append(deferred, file)
+		}
+	}
+	return rest, deferred
+}
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"upframer-worker/internal/domain/ports"
@@ -9,36 +12,125 @@ import (
 )
 
 type LocalStorage struct {
-	basePath   string
-	zipAdapter *util.ZipAdapter
+	basePath string
 }
 
 func NewLocalStorage(basePath string) *LocalStorage {
 	return &LocalStorage{
-		basePath:   basePath,
-		zipAdapter: util.NewZipAdapter(),
+		basePath: basePath,
 	}
 }
 
-func (ls *LocalStorage) StoreZip(sourceDir, zipFileName string) (*ports.StorageResult, error) {
-	err := os.MkdirAll(ls.basePath, 0755)
+func (ls *LocalStorage) StoreArchive(ctx context.Context, sourceDir, fileName, format string) (*ports.StorageResult, error) {
+	archiver, err := util.NewArchiver(format)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(ls.basePath, 0755)
 	if err != nil {
 		return nil, fmt.Errorf("error creating base directory: %v", err)
 	}
 
-	zipPath := filepath.Join(ls.basePath, zipFileName)
+	archivePath := filepath.Join(ls.basePath, fileName)
 
-	err = ls.zipAdapter.CreateZipFile(sourceDir, zipPath)
+	info, err := util.CreateArchive(archiver, sourceDir, archivePath)
 	if err != nil {
-		return nil, fmt.Errorf("error creating zip: %v", err)
+		return nil, fmt.Errorf("error creating archive: %v", err)
 	}
 
 	return &ports.StorageResult{
-		Path: zipPath,
-		URL:  fmt.Sprintf("file://%s", zipPath),
+		Path:        archivePath,
+		URL:         fmt.Sprintf("file://%s", archivePath),
+		Size:        info.Size,
+		Checksum:    info.Checksum,
+		ContentType: archiver.ContentType(),
 	}, nil
 }
 
-func (ls *LocalStorage) Download(s3Key, localPath string) error {
+func (ls *LocalStorage) StoreFile(ctx context.Context, localPath, name, contentType string) (*ports.StorageResult, error) {
+	targetPath := filepath.Join(ls.basePath, filepath.FromSlash(name))
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return nil, fmt.Errorf("error creating directory: %v", err)
+	}
+
+	source, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+	defer source.Close()
+
+	target, err := os.Create(targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("error creating file: %v", err)
+	}
+	defer target.Close()
+
+	if _, err := io.Copy(target, source); err != nil {
+		return nil, fmt.Errorf("error copying file: %v", err)
+	}
+
+	if err := target.Close(); err != nil {
+		return nil, fmt.Errorf("error writing file: %v", err)
+	}
+
+	info, err := util.ChecksumFile(targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("error hashing file: %v", err)
+	}
+
+	return &ports.StorageResult{
+		Path:        targetPath,
+		URL:         fmt.Sprintf("file://%s", targetPath),
+		Size:        info.Size,
+		Checksum:    info.Checksum,
+		ContentType: contentType,
+	}, nil
+}
+
+func (ls *LocalStorage) UploadDirectory(ctx context.Context, sourceDir, prefix string, last ...string) (*ports.DirectoryResult, error) {
+	files, err := listDirectory(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("error listing directory: %v", err)
+	}
+
+	rest, deferred := splitLast(files, last)
+
+	result := &ports.DirectoryResult{
+		Prefix: filepath.Join(ls.basePath, filepath.FromSlash(prefix)),
+		Files:  make(map[string]*ports.StorageResult, len(files)),
+	}
+
+	for _, file := range append(rest, deferred...) {
+		if err := ctx.Err(); err != nil {
+			removeStored(result)
+			return nil, err
+		}
+
+		stored, err := ls.StoreFile(ctx, file.path, prefix+file.name, file.contentType)
+		if err != nil {
+			removeStored(result)
+			return nil, err
+		}
+		result.Files[file.name] = stored
+	}
+
+	return result, nil
+}
+
+func removeStored(result *ports.DirectoryResult) {
+	for _, stored := range result.Files {
+		if err := os.Remove(stored.Path); err != nil {
+			log.Printf("Warning: error removing %s: %v", stored.Path, err)
+		}
+	}
+}
+
+func (ls *LocalStorage) ObjectSize(ctx context.Context, s3Key string) (int64, error) {
+	return 0, fmt.Errorf("ObjectSize is not supported for LocalStorage")
+}
+
+func (ls *LocalStorage) Download(ctx context.Context, s3Key, localPath string) error {
 	return fmt.Errorf("Download is not supported for LocalStorage")
 }
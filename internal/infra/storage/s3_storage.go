@@ -2,87 +2,411 @@ package storage
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sync"
+	"time"
 	"upframer-worker/internal/domain/ports"
 	"upframer-worker/internal/infra/util"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+const (
+	defaultUploadConcurrency = 8
+	// DeleteObjects takes at most 1000 keys per request.
+	maxDeleteBatch = 1000
+	cleanupTimeout = 30 * time.Second
+)
+
+const (
+	DispositionAttachment = "attachment"
+	DispositionInline     = "inline"
+)
+
+type S3Config struct {
+	Bucket            string
+	Region            string
+	AccessKey         string
+	SecretKey         string
+	SessionToken      string
+	UploadConcurrency int
+	// PresignExpiry enables presigned GET URLs for stored artifacts when
+	// positive.
+	PresignExpiry time.Duration
+	// ContentDisposition is DispositionAttachment, which names the download
+	// after the artifact, or DispositionInline.
+	ContentDisposition string
+	// Endpoint points the client at an S3-compatible store such as MinIO or
+	// LocalStack instead of AWS, e.g. http://localhost:9000.
+	Endpoint string
+	// UsePathStyle addresses objects as endpoint/bucket/key rather than
+	// bucket.endpoint/key, which most S3-compatible stores require.
+	UsePathStyle bool
+	// CABundle is a PEM file with extra CAs to trust, for endpoints behind a
+	// private CA.
+	CABundle           string
+	InsecureSkipVerify bool
+}
+
 type S3Storage struct {
-	bucket     string
-	client     *s3.Client
-	zipAdapter *util.ZipAdapter
+	config  S3Config
+	client  *s3.Client
+	presign *s3.PresignClient
 }
 
-func NewS3Storage(bucket, region, accessKey, secretKey, sessionToken string) (*S3Storage, error) {
+func NewS3Storage(s3Config S3Config) (*S3Storage, error) {
+	httpClient, err := newHTTPClient(s3Config)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(region),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, sessionToken)),
+		config.WithRegion(s3Config.Region),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(s3Config.AccessKey, s3Config.SecretKey, s3Config.SessionToken)),
+		config.WithHTTPClient(httpClient),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %v", err)
 	}
 
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if s3Config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(s3Config.Endpoint)
+		}
+		o.UsePathStyle = s3Config.UsePathStyle
+	})
+
+	if s3Config.UploadConcurrency <= 0 {
+		s3Config.UploadConcurrency = defaultUploadConcurrency
+	}
 
 	return &S3Storage{
-		bucket:     bucket,
-		client:     client,
-		zipAdapter: util.NewZipAdapter(),
+		config:  s3Config,
+		client:  client,
+		presign: s3.NewPresignClient(client),
 	}, nil
 }
 
-func (s *S3Storage) StoreZip(sourceDir, zipFileName string) (*ports.StorageResult, error) {
-	tempDir, err := os.MkdirTemp("", "upframer-secure-*")
+// newHTTPClient applies the TLS options on top of the SDK's default transport.
+func newHTTPClient(s3Config S3Config) (*awshttp.BuildableClient, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: s3Config.InsecureSkipVerify,
+	}
+
+	if s3Config.CABundle != "" {
+		pem, err := os.ReadFile(s3Config.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+		}
+
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", s3Config.CABundle)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	return awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+		t.TLSClientConfig = tlsConfig
+	}), nil
+}
+
+// presignResult adds a presigned GET URL to result when presigning is
+// enabled. Signing happens locally, without a request to S3.
+func (s *S3Storage) presignResult(ctx context.Context, result *ports.StorageResult) error {
+	if s.config.PresignExpiry <= 0 {
+		return nil
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(result.Path),
+	}
+
+	switch s.config.ContentDisposition {
+	case DispositionInline:
+		input.ResponseContentDisposition = aws.String(DispositionInline)
+	default:
+		input.ResponseContentDisposition = aws.String(fmt.Sprintf("%s; filename=%q", DispositionAttachment, path.Base(result.Path)))
+	}
+
+	// taken before signing, so the reported expiry is never later than the
+	// one in the signature
+	expiresAt := time.Now().Add(s.config.PresignExpiry).UTC()
+
+	request, err := s.presign.PresignGetObject(ctx, input, s3.WithPresignExpires(s.config.PresignExpiry))
+	if err != nil {
+		return fmt.Errorf("failed to presign %s: %v", result.Path, err)
+	}
+
+	result.PresignedURL = request.URL
+	result.PresignedURLExpiresAt = &expiresAt
+	return nil
+}
+
+// StoreArchive streams the archive straight into a multipart upload, so it
+// never touches the disk. A failure on either end of the pipe fails the other
+// and the uploader aborts the multipart upload.
+func (s *S3Storage) StoreArchive(ctx context.Context, sourceDir, fileName, format string) (*ports.StorageResult, error) {
+	archiver, err := util.NewArchiver(format)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create secure temp directory: %v", err)
+		return nil, err
 	}
-	defer os.RemoveAll(tempDir)
 
-	if err := os.Chmod(tempDir, 0700); err != nil {
-		return nil, fmt.Errorf("failed to set secure permissions: %v", err)
+	s3Key := fmt.Sprintf("results/%s", fileName)
+
+	reader, writer := io.Pipe()
+
+	var (
+		info       *util.ArchiveInfo
+		archiveErr error
+		done       = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+		info, archiveErr = util.StreamArchive(archiver, sourceDir, writer)
+		writer.CloseWithError(archiveErr)
+	}()
+
+	uploader := manager.NewUploader(s.client, func(u *manager.Uploader) {
+		u.LeavePartsOnError = false
+	})
+
+	result, err := uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.config.Bucket),
+		Key:         aws.String(s3Key),
+		Body:        reader,
+		ContentType: aws.String(archiver.ContentType()),
+	})
+
+	// unblocks the archive writer if the upload gave up before reading
+	// everything
+	reader.CloseWithError(err)
+	<-done
+
+	// an archive error that is just the upload error coming back through
+	// the pipe is reported as the upload failure it is
+	if archiveErr != nil && !errors.Is(archiveErr, err) {
+		return nil, fmt.Errorf("failed to create archive: %v", archiveErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload archive to S3: %v", err)
+	}
+
+	stored := &ports.StorageResult{
+		Path:        s3Key,
+		URL:         result.Location,
+		Size:        info.Size,
+		Checksum:    info.Checksum,
+		ContentType: archiver.ContentType(),
+	}
+	if err := s.presignResult(ctx, stored); err != nil {
+		return nil, err
 	}
 
-	tempZipPath := filepath.Join(tempDir, zipFileName)
+	return stored, nil
+}
 
-	err = s.zipAdapter.CreateZipFile(sourceDir, tempZipPath)
+func (s *S3Storage) StoreFile(ctx context.Context, localPath, name, contentType string) (*ports.StorageResult, error) {
+	stored, err := s.putFile(ctx, localPath, name, contentType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create zip file: %v", err)
+		return nil, err
+	}
+
+	if err := s.presignResult(ctx, stored); err != nil {
+		return nil, err
 	}
 
-	uploader := manager.NewUploader(s.client)
+	return stored, nil
+}
+
+// putFile uploads a single file without presigning it.
+func (s *S3Storage) putFile(ctx context.Context, localPath, name, contentType string) (*ports.StorageResult, error) {
+	info, err := util.ChecksumFile(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash file: %v", err)
+	}
 
-	file, err := os.Open(tempZipPath)
+	file, err := os.Open(localPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open zip file: %v", err)
+		return nil, fmt.Errorf("failed to open file: %v", err)
 	}
 	defer file.Close()
 
-	s3Key := fmt.Sprintf("results/%s", zipFileName)
+	s3Key := fmt.Sprintf("results/%s", name)
 
-	result, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(s3Key),
-		Body:   file,
+	result, err := manager.NewUploader(s.client).Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.config.Bucket),
+		Key:         aws.String(s3Key),
+		Body:        file,
+		ContentType: aws.String(contentType),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload zip to S3: %v", err)
+		return nil, fmt.Errorf("failed to upload file to S3: %v", err)
 	}
 
 	return &ports.StorageResult{
-		Path: s3Key,
-		URL:  result.Location,
+		Path:        s3Key,
+		URL:         result.Location,
+		Size:        info.Size,
+		Checksum:    info.Checksum,
+		ContentType: contentType,
 	}, nil
 }
 
-func (s *S3Storage) Download(s3Key, localPath string) error {
+// UploadDirectory uploads every file under sourceDir as its own object below
+// prefix, at most uploadConcurrency at a time, and then the files named in
+// last one by one. Only the files in last are presigned: they are the entry
+// points handed to clients, and signing thousands of frames nobody links to
+// directly is wasted work. The first failure cancels the uploads still in flight and
+// deletes the objects already written, so a listing in last never points at
+// missing objects.
+func (s *S3Storage) UploadDirectory(ctx context.Context, sourceDir, prefix string, last ...string) (*ports.DirectoryResult, error) {
+	files, err := listDirectory(sourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directory: %v", err)
+	}
+
+	rest, deferred := splitLast(files, last)
+
+	result := &ports.DirectoryResult{
+		Prefix: fmt.Sprintf("results/%s", prefix),
+		Files:  make(map[string]*ports.StorageResult, len(files)),
+	}
+
+	if err := s.uploadFiles(ctx, rest, prefix, result); err != nil {
+		s.deleteStored(ctx, result)
+		return nil, err
+	}
+
+	for _, file := range deferred {
+		stored, err := s.StoreFile(ctx, file.path, prefix+file.name, file.contentType)
+		if err != nil {
+			s.deleteStored(ctx, result)
+			return nil, fmt.Errorf("failed to upload %s: %w", file.name, err)
+		}
+		result.Files[file.name] = stored
+	}
+
+	return result, nil
+}
+
+// uploadFiles uploads files in parallel, adding each stored file to result.
+func (s *S3Storage) uploadFiles(ctx context.Context, files []directoryFile, prefix string, result *ports.DirectoryResult) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		sem      = make(chan struct{}, s.config.UploadConcurrency)
+	)
+
+	for _, file := range files {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(file directoryFile) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			stored, err := s.putFile(ctx, file.path, prefix+file.name, file.contentType)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to upload %s: %w", file.name, err)
+					cancel()
+				}
+				return
+			}
+			result.Files[file.name] = stored
+		}(file)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// deleteStored removes the objects of a failed directory upload. It runs even
+// when ctx was cancelled, which is often why the upload failed.
+func (s *S3Storage) deleteStored(ctx context.Context, result *ports.DirectoryResult) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+
+	var objects []types.ObjectIdentifier
+	for _, stored := range result.Files {
+		objects = append(objects, types.ObjectIdentifier{Key: aws.String(stored.Path)})
+	}
+
+	for len(objects) > 0 {
+		batch := objects[:min(len(objects), maxDeleteBatch)]
+		objects = objects[len(batch):]
+
+		output, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.config.Bucket),
+			Delete: &types.Delete{Objects: batch, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			log.Printf("Warning: error deleting %d objects below %s: %v", len(batch), result.Prefix, err)
+			continue
+		}
+		for _, failed := range output.Errors {
+			log.Printf("Warning: error deleting %s: %s", aws.ToString(failed.Key), aws.ToString(failed.Message))
+		}
+	}
+}
+
+func (s *S3Storage) ObjectSize(ctx context.Context, s3Key string) (int64, error) {
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(s3Key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return 0, fmt.Errorf("object %s not found: %v", s3Key, err)
+		}
+		return 0, fmt.Errorf("failed to stat file on S3: %v", err)
+	}
+
+	return aws.ToInt64(head.ContentLength), nil
+}
+
+func (s *S3Storage) Download(ctx context.Context, s3Key, localPath string) error {
 	downloader := manager.NewDownloader(s.client)
 
 	err := os.MkdirAll(filepath.Dir(localPath), 0755)
@@ -96,8 +420,8 @@ func (s *S3Storage) Download(s3Key, localPath string) error {
 	}
 	defer file.Close()
 
-	_, err = downloader.Download(context.TODO(), file, &s3.GetObjectInput{
-		Bucket: aws.String(s.bucket),
+	_, err = downloader.Download(ctx, file, &s3.GetObjectInput{
+		Bucket: aws.String(s.config.Bucket),
 		Key:    aws.String(s3Key),
 	})
 	if err != nil {
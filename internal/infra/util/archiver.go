@@ -0,0 +1,60 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"upframer-worker/internal/domain/entities"
+)
+
+// Archiver packs every file under a directory into a single archive stream.
+type Archiver interface {
+	WriteArchive(sourceDir string, w io.Writer) error
+	ContentType() string
+}
+
+func NewArchiver(format string) (Archiver, error) {
+	switch format {
+	case "", entities.ArchiveZip:
+		return NewZipAdapter(), nil
+	case entities.ArchiveTar:
+		return newTarArchiver(compressionNone), nil
+	case entities.ArchiveTarGz:
+		return newTarArchiver(compressionGzip), nil
+	case entities.ArchiveTarZst:
+		return newTarArchiver(compressionZstd), nil
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+// CreateArchive writes the archive to path and returns its size and checksum.
+func CreateArchive(archiver Archiver, sourceDir, path string) (*ArchiveInfo, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := StreamArchive(archiver, sourceDir, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// StreamArchive writes the archive to w and returns its size and checksum.
+func StreamArchive(archiver Archiver, sourceDir string, w io.Writer) (*ArchiveInfo, error) {
+	counter := newChecksumWriter(w)
+
+	if err := archiver.WriteArchive(sourceDir, counter); err != nil {
+		return nil, err
+	}
+
+	return counter.Info(), nil
+}
@@ -0,0 +1,169 @@
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"upframer-worker/internal/domain/entities"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var archiveFiles = map[string]string{
+	"frame_0001.jpg":          "first frame",
+	"frame_0002.jpg":          "second frame",
+	"frames.csv":              "index,file,timestamp\n1,frame_0001.jpg,0\n2,frame_0002.jpg,1\n",
+	"sprites/thumbnails.vtt":  "WEBVTT\n",
+	"sprites/sprite_001.jpg":  "",
+	"sprites/nested/deep.txt": "deep",
+}
+
+func writeArchiveSource(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range archiveFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Expected a valid zip, got %v", err)
+	}
+
+	entries := map[string]string{}
+	for _, entry := range reader.File {
+		file, err := entry.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[entry.Name] = string(content)
+	}
+	return entries
+}
+
+func readTar(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+
+	reader := tar.NewReader(r)
+	entries := map[string]string{}
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("Expected a valid tar, got %v", err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[header.Name] = string(content)
+	}
+}
+
+func TestArchiver_RoundTrip(t *testing.T) {
+	tests := []struct {
+		format      string
+		contentType string
+		read        func(*testing.T, []byte) map[string]string
+	}{
+		{"", "application/zip", readZip},
+		{entities.ArchiveZip, "application/zip", readZip},
+		{entities.ArchiveTar, "application/x-tar", func(t *testing.T, data []byte) map[string]string {
+			return readTar(t, bytes.NewReader(data))
+		}},
+		{entities.ArchiveTarGz, "application/gzip", func(t *testing.T, data []byte) map[string]string {
+			reader, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Expected a valid gzip stream, got %v", err)
+			}
+			defer reader.Close()
+			return readTar(t, reader)
+		}},
+		{entities.ArchiveTarZst, "application/zstd", func(t *testing.T, data []byte) map[string]string {
+			reader, err := zstd.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Expected a valid zstd stream, got %v", err)
+			}
+			defer reader.Close()
+			return readTar(t, reader)
+		}},
+	}
+
+	sourceDir := writeArchiveSource(t)
+
+	for _, tt := range tests {
+		archiver, err := NewArchiver(tt.format)
+		if err != nil {
+			t.Fatalf("%q: expected an archiver, got %v", tt.format, err)
+		}
+
+		if archiver.ContentType() != tt.contentType {
+			t.Errorf("%q: expected content type %s, got %s", tt.format, tt.contentType, archiver.ContentType())
+		}
+
+		archivePath := filepath.Join(t.TempDir(), "frames")
+		info, err := CreateArchive(archiver, sourceDir, archivePath)
+		if err != nil {
+			t.Fatalf("%q: expected the archive to be created, got %v", tt.format, err)
+		}
+
+		data, err := os.ReadFile(archivePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checksum, err := ChecksumFile(archivePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size != int64(len(data)) || info.Checksum != checksum.Checksum {
+			t.Errorf("%q: expected size %d and checksum %s, got %d and %s", tt.format, len(data), checksum.Checksum, info.Size, info.Checksum)
+		}
+
+		entries := tt.read(t, data)
+
+		if len(entries) != len(archiveFiles) {
+			t.Errorf("%q: expected %d entries, got %d: %v", tt.format, len(archiveFiles), len(entries), entries)
+		}
+		for name, content := range archiveFiles {
+			got, ok := entries[name]
+			if !ok {
+				t.Errorf("%q: expected entry %s", tt.format, name)
+				continue
+			}
+			if got != content {
+				t.Errorf("%q: expected %s to contain %q, got %q", tt.format, name, content, got)
+			}
+		}
+	}
+}
+
+func TestNewArchiver_UnsupportedFormat(t *testing.T) {
+	if _, err := NewArchiver("rar"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
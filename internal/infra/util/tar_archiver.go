@@ -0,0 +1,107 @@
+package util
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type compression int
+
+const (
+	compressionNone compression = iota
+	compressionGzip
+	compressionZstd
+)
+
+type tarArchiver struct {
+	compression compression
+}
+
+func newTarArchiver(compression compression) *tarArchiver {
+	return &tarArchiver{compression: compression}
+}
+
+func (t *tarArchiver) ContentType() string {
+	switch t.compression {
+	case compressionGzip:
+		return "application/gzip"
+	case compressionZstd:
+		return "application/zstd"
+	default:
+		return "application/x-tar"
+	}
+}
+
+func (t *tarArchiver) WriteArchive(sourceDir string, w io.Writer) error {
+	compressed, err := t.compress(w)
+	if err != nil {
+		return err
+	}
+
+	tarWriter := tar.NewWriter(compressed)
+
+	err = filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+
+	return compressed.Close()
+}
+
+func (t *tarArchiver) compress(w io.Writer) (io.WriteCloser, error) {
+	switch t.compression {
+	case compressionGzip:
+		return gzip.NewWriter(w), nil
+	case compressionZstd:
+		return zstd.NewWriter(w)
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
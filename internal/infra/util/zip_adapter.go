@@ -2,28 +2,37 @@ package util
 
 import (
 	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
 )
 
+type ArchiveInfo struct {
+	Size     int64
+	Checksum string
+}
+
 type ZipAdapter struct{}
 
 func NewZipAdapter() *ZipAdapter {
 	return &ZipAdapter{}
 }
 
-func (z *ZipAdapter) CreateZipFile(sourceDir, zipPath string) error {
-	zipFile, err := os.Create(zipPath)
-	if err != nil {
-		return err
-	}
-	defer zipFile.Close()
+func (z *ZipAdapter) ContentType() string {
+	return "application/zip"
+}
+
+func (z *ZipAdapter) WriteArchive(sourceDir string, w io.Writer) error {
+	return z.WriteZip(sourceDir, w)
+}
 
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+func (z *ZipAdapter) WriteZip(sourceDir string, w io.Writer) error {
+	zipWriter := zip.NewWriter(w)
 
-	return filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -37,7 +46,7 @@ func (z *ZipAdapter) CreateZipFile(sourceDir, zipPath string) error {
 			return err
 		}
 
-		zipEntry, err := zipWriter.Create(relPath)
+		zipEntry, err := zipWriter.Create(filepath.ToSlash(relPath))
 		if err != nil {
 			return err
 		}
@@ -51,4 +60,49 @@ func (z *ZipAdapter) CreateZipFile(sourceDir, zipPath string) error {
 		_, err = io.Copy(zipEntry, file)
 		return err
 	})
+	if err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
+// ChecksumFile returns the size and SHA-256 of the file at path.
+func ChecksumFile(path string) (*ArchiveInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	counter := newChecksumWriter(io.Discard)
+	if _, err := io.Copy(counter, file); err != nil {
+		return nil, err
+	}
+
+	return counter.Info(), nil
+}
+
+type checksumWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	size int64
+}
+
+func newChecksumWriter(w io.Writer) *checksumWriter {
+	return &checksumWriter{w: w, hash: sha256.New()}
+}
+
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.hash.Write(p[:n])
+	c.size += int64(n)
+	return n, err
+}
+
+func (c *checksumWriter) Info() *ArchiveInfo {
+	return &ArchiveInfo{
+		Size:     c.size,
+		Checksum: hex.EncodeToString(c.hash.Sum(nil)),
+	}
 }
@@ -0,0 +1,60 @@
+package workspace
+
+import (
+	"context"
+	"sync"
+)
+
+type DiskBudget struct {
+	mu      sync.Mutex
+	limit   int64
+	used    int64
+	changed chan struct{}
+}
+
+func NewDiskBudget(limit int64) *DiskBudget {
+	return &DiskBudget{
+		limit:   limit,
+		changed: make(chan struct{}),
+	}
+}
+
+func (b *DiskBudget) Acquire(ctx context.Context, bytes int64) (int64, error) {
+	if b == nil || b.limit <= 0 || bytes <= 0 {
+		return 0, nil
+	}
+
+	if bytes > b.limit {
+		bytes = b.limit
+	}
+
+	for {
+		b.mu.Lock()
+		if b.used+bytes <= b.limit {
+			b.used += bytes
+			b.mu.Unlock()
+			return bytes, nil
+		}
+		changed := b.changed
+		b.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func (b *DiskBudget) Release(bytes int64) {
+	if b == nil || bytes <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.used -= bytes
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
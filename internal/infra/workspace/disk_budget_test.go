@@ -0,0 +1,89 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDiskBudget_Unlimited(t *testing.T) {
+	var nilBudget *DiskBudget
+
+	for _, budget := range []*DiskBudget{nilBudget, NewDiskBudget(0)} {
+		acquired, err := budget.Acquire(context.Background(), 1<<40)
+		if err != nil || acquired != 0 {
+			t.Errorf("Expected an unlimited budget to reserve nothing, got %d, %v", acquired, err)
+		}
+		budget.Release(acquired)
+	}
+}
+
+func TestDiskBudget_ClampsToLimit(t *testing.T) {
+	budget := NewDiskBudget(100)
+
+	acquired, err := budget.Acquire(context.Background(), 250)
+	if err != nil {
+		t.Fatalf("Failed to acquire: %v", err)
+	}
+	if acquired != 100 {
+		t.Errorf("Expected a reservation above the limit to be clamped to 100, got %d", acquired)
+	}
+}
+
+func TestDiskBudget_BlocksUntilReleased(t *testing.T) {
+	budget := NewDiskBudget(100)
+
+	first, err := budget.Acquire(context.Background(), 60)
+	if err != nil {
+		t.Fatalf("Failed to acquire: %v", err)
+	}
+
+	acquired := make(chan int64)
+	go func() {
+		bytes, _ := budget.Acquire(context.Background(), 60)
+		acquired <- bytes
+	}()
+
+	select {
+	case bytes := <-acquired:
+		t.Fatalf("Expected the second reservation to wait, got %d", bytes)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	budget.Release(first)
+
+	select {
+	case bytes := <-acquired:
+		if bytes != 60 {
+			t.Errorf("Expected 60 bytes reserved, got %d", bytes)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the second reservation to go through after the release")
+	}
+}
+
+func TestDiskBudget_ContextCancel(t *testing.T) {
+	budget := NewDiskBudget(100)
+
+	if _, err := budget.Acquire(context.Background(), 100); err != nil {
+		t.Fatalf("Failed to acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	acquired, err := budget.Acquire(ctx, 10)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if acquired != 0 {
+		t.Errorf("Expected nothing reserved, got %d", acquired)
+	}
+
+	// the cancelled wait must not have taken any of the budget
+	budget.Release(100)
+	if acquired, err := budget.Acquire(context.Background(), 100); err != nil || acquired != 100 {
+		t.Errorf("Expected the full budget back, got %d, %v", acquired, err)
+	}
+}
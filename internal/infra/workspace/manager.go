@@ -0,0 +1,198 @@
+package workspace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	dirPrefix     = "job-"
+	ownerFileName = ".owner"
+	framesDirName = "frames"
+	maxJobIdChars = 64
+)
+
+// instanceId tells this run of the worker apart from earlier ones that had
+// the same PID, as happens when a container restarts with the worker as PID 1.
+var instanceId = newInstanceId()
+
+type Manager struct {
+	root     string
+	maxAge   time.Duration
+	hostname string
+	budget   *DiskBudget
+}
+
+type Workspace struct {
+	Dir      string
+	JobId    string
+	budget   *DiskBudget
+	reserved int64
+}
+
+func NewManager(root string, maxAge time.Duration, budget *DiskBudget) (*Manager, error) {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, fmt.Errorf("error creating workspace root: %v", err)
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &Manager{
+		root:     root,
+		maxAge:   maxAge,
+		hostname: hostname,
+		budget:   budget,
+	}, nil
+}
+
+func (m *Manager) Root() string {
+	return m.root
+}
+
+func (m *Manager) Create(jobId string) (*Workspace, error) {
+	dir, err := os.MkdirTemp(m.root, dirPrefix+sanitizeJobId(jobId)+"-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating workspace: %v", err)
+	}
+
+	ws := &Workspace{Dir: dir, JobId: jobId, budget: m.budget}
+
+	owner := fmt.Sprintf("%s\n%d\n%s\n", m.hostname, os.Getpid(), instanceId)
+	if err := os.WriteFile(filepath.Join(dir, ownerFileName), []byte(owner), 0600); err != nil {
+		ws.Cleanup()
+		return nil, fmt.Errorf("error writing workspace owner: %v", err)
+	}
+
+	if err := os.Mkdir(ws.FramesDir(), 0700); err != nil {
+		ws.Cleanup()
+		return nil, fmt.Errorf("error creating frames directory: %v", err)
+	}
+
+	return ws, nil
+}
+
+func (m *Manager) ReclaimOrphans() (int, error) {
+	entries, err := os.ReadDir(m.root)
+	if err != nil {
+		return 0, fmt.Errorf("error reading workspace root: %v", err)
+	}
+
+	reclaimed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), dirPrefix) {
+			continue
+		}
+
+		dir := filepath.Join(m.root, entry.Name())
+		if !m.isOrphan(dir) {
+			continue
+		}
+
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Warning: error removing orphaned workspace %s: %v", dir, err)
+			continue
+		}
+		reclaimed++
+	}
+
+	return reclaimed, nil
+}
+
+func (m *Manager) isOrphan(dir string) bool {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	expired := m.maxAge > 0 && time.Since(info.ModTime()) > m.maxAge
+
+	data, err := os.ReadFile(filepath.Join(dir, ownerFileName))
+	if err != nil {
+		return expired
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) < 2 || lines[0] != m.hostname {
+		return expired
+	}
+
+	pid, err := strconv.Atoi(lines[1])
+	if err != nil {
+		return expired
+	}
+
+	if pid == os.Getpid() {
+		// our PID, but only ours if this run wrote it
+		return len(lines) < 3 || lines[2] != instanceId
+	}
+
+	return expired || !processAlive(pid)
+}
+
+func (w *Workspace) FramesDir() string {
+	return filepath.Join(w.Dir, framesDirName)
+}
+
+func (w *Workspace) Path(name string) string {
+	return filepath.Join(w.Dir, name)
+}
+
+func (w *Workspace) Reserve(ctx context.Context, bytes int64) error {
+	acquired, err := w.budget.Acquire(ctx, bytes)
+	if err != nil {
+		return fmt.Errorf("error reserving disk for workspace: %w", err)
+	}
+	w.reserved += acquired
+	return nil
+}
+
+func (w *Workspace) Cleanup() {
+	if err := os.RemoveAll(w.Dir); err != nil {
+		log.Printf("Warning: error removing workspace %s: %v", w.Dir, err)
+	}
+
+	w.budget.Release(w.reserved)
+	w.reserved = 0
+}
+
+func newInstanceId() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+func sanitizeJobId(jobId string) string {
+	var b strings.Builder
+	for _, r := range jobId {
+		if b.Len() >= maxJobIdChars {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
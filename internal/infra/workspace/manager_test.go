@@ -0,0 +1,64 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManager_ReclaimOrphans(t *testing.T) {
+	root := t.TempDir()
+	manager, err := NewManager(root, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	live, err := manager.Create("live")
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+
+	owners := map[string]string{
+		// a previous run of the worker with the same PID, e.g. a restarted container
+		"job-restarted": fmt.Sprintf("%s\n%d\nprevious-run\n", manager.hostname, os.Getpid()),
+		// written before owners carried an instance id
+		"job-legacy":   fmt.Sprintf("%s\n%d\n", manager.hostname, os.Getpid()),
+		"job-no-owner": "",
+	}
+	for name, owner := range owners {
+		dir := filepath.Join(root, name)
+		if err := os.Mkdir(dir, 0700); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		if owner != "" {
+			if err := os.WriteFile(filepath.Join(dir, ownerFileName), []byte(owner), 0600); err != nil {
+				t.Fatalf("Failed to write owner of %s: %v", name, err)
+			}
+		}
+	}
+
+	// the owner-less workspace is only reclaimed once it expires
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "job-no-owner"), old, old); err != nil {
+		t.Fatalf("Failed to age workspace: %v", err)
+	}
+
+	reclaimed, err := manager.ReclaimOrphans()
+	if err != nil {
+		t.Fatalf("Failed to reclaim orphans: %v", err)
+	}
+	if reclaimed != len(owners) {
+		t.Errorf("Expected %d reclaimed workspaces, got %d", len(owners), reclaimed)
+	}
+
+	if _, err := os.Stat(live.Dir); err != nil {
+		t.Errorf("Expected live workspace to be kept, got %v", err)
+	}
+	for name := range owners {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be reclaimed", name)
+		}
+	}
+}